@@ -0,0 +1,207 @@
+package avlgo
+
+// Op tells Compute() what to do with the value returned by its function
+type Op int
+
+const (
+	OpKeep   Op = iota // leave the tree unchanged
+	OpPut              // put the returned value for the key
+	OpDelete           // delete the key
+)
+
+// Compute() atomically reads, modifies and writes the value of the key
+// fn receives the current value of the key (ok is false if absent) and returns the new value and what to do with it
+// The tree is locked once, and descended once, during the whole operation, so fn must not use the tree
+// It returns the value of the key after the operation, and false if the key is absent
+func (t *Tree[K, V]) Compute(key K, fn func(old V, ok bool) (V, Op)) (value V, ok bool) {
+	value, ok, _ = t.compute(key, fn)
+	return value, ok
+}
+
+// Compute() reads, modifies and writes the value of the key with one descent, like Tree.Compute()
+func (t *UnsyncTree[K, V]) Compute(key K, fn func(old V, ok bool) (V, Op)) (value V, ok bool) {
+	value, ok, _ = t.compute(key, fn)
+	return value, ok
+}
+
+// GetOrPut() returns the value of the key if present (loaded is true)
+// otherwise it puts value and returns it (loaded is false)
+func (t *Tree[K, V]) GetOrPut(key K, value V) (actual V, loaded bool) {
+	actual, _, _ = t.compute(key, getOrPut(value, &loaded))
+	return actual, loaded
+}
+
+// GetOrPut() returns the value of the key if present (loaded is true)
+// otherwise it puts value and returns it (loaded is false)
+func (t *UnsyncTree[K, V]) GetOrPut(key K, value V) (actual V, loaded bool) {
+	actual, _, _ = t.compute(key, getOrPut(value, &loaded))
+	return actual, loaded
+}
+
+// PutIfAbsent() puts the key/value only if the key is absent and returns true if it was put
+// On a bounded tree, it returns false if the key was evicted
+func (t *Tree[K, V]) PutIfAbsent(key K, value V) bool {
+	put := false
+	_, _, kept := t.compute(key, putIfAbsent(value, &put))
+	return put && kept
+}
+
+// PutIfAbsent() puts the key/value only if the key is absent and returns true if it was put
+// On a bounded tree, it returns false if the key was evicted
+func (t *UnsyncTree[K, V]) PutIfAbsent(key K, value V) bool {
+	put := false
+	_, _, kept := t.compute(key, putIfAbsent(value, &put))
+	return put && kept
+}
+
+// Update() replaces the value of the key by fn(old) only if the key is present
+// It returns the new value, and false if the key is absent (fn isn't called)
+// fn is called with the tree locked, so it must not use the tree
+func (t *Tree[K, V]) Update(key K, fn func(old V) V) (value V, ok bool) {
+	value, ok, _ = t.compute(key, update(fn))
+	return value, ok
+}
+
+// Update() replaces the value of the key by fn(old) only if the key is present, like Tree.Update()
+func (t *UnsyncTree[K, V]) Update(key K, fn func(old V) V) (value V, ok bool) {
+	value, ok, _ = t.compute(key, update(fn))
+	return value, ok
+}
+
+// CompareAndSwap() replaces the value of the key by value only if its current value is equal to old
+// according to eq, and returns true if the value was swapped
+func (t *Tree[K, V]) CompareAndSwap(key K, old, value V, eq func(a, b V) bool) (swapped bool) {
+	t.compute(key, compareAndSwap(old, value, eq, &swapped))
+	return swapped
+}
+
+// CompareAndSwap() replaces the value of the key by value only if its current value is equal to old
+// according to eq, and returns true if the value was swapped
+func (t *UnsyncTree[K, V]) CompareAndSwap(key K, old, value V, eq func(a, b V) bool) (swapped bool) {
+	t.compute(key, compareAndSwap(old, value, eq, &swapped))
+	return swapped
+}
+
+// CompareAndDelete() deletes the key only if its current value is equal to old according to eq,
+// and returns true if the key was deleted
+func (t *Tree[K, V]) CompareAndDelete(key K, old V, eq func(a, b V) bool) (deleted bool) {
+	t.compute(key, compareAndDelete(old, eq, &deleted))
+	return deleted
+}
+
+// CompareAndDelete() deletes the key only if its current value is equal to old according to eq,
+// and returns true if the key was deleted
+func (t *UnsyncTree[K, V]) CompareAndDelete(key K, old V, eq func(a, b V) bool) (deleted bool) {
+	t.compute(key, compareAndDelete(old, eq, &deleted))
+	return deleted
+}
+
+// getOrPut(), putIfAbsent(), update(), compareAndSwap() and compareAndDelete() return the functions given to compute()
+// by the methods of the same name. They report their outcome in the last argument
+func getOrPut[V any](value V, loaded *bool) func(old V, ok bool) (V, Op) {
+	return func(old V, ok bool) (V, Op) {
+		if ok {
+			*loaded = true
+			return old, OpKeep
+		}
+		return value, OpPut
+	}
+}
+
+func putIfAbsent[V any](value V, put *bool) func(old V, ok bool) (V, Op) {
+	return func(old V, ok bool) (V, Op) {
+		if ok {
+			return old, OpKeep
+		}
+		*put = true
+		return value, OpPut
+	}
+}
+
+func update[V any](fn func(old V) V) func(current V, ok bool) (V, Op) {
+	return func(current V, ok bool) (V, Op) {
+		if !ok {
+			return current, OpKeep
+		}
+		return fn(current), OpPut
+	}
+}
+
+func compareAndSwap[V any](old, value V, eq func(a, b V) bool, swapped *bool) func(current V, ok bool) (V, Op) {
+	return func(current V, ok bool) (V, Op) {
+		if ok && eq(current, old) {
+			*swapped = true
+			return value, OpPut
+		}
+		return current, OpKeep
+	}
+}
+
+func compareAndDelete[V any](old V, eq func(a, b V) bool, deleted *bool) func(current V, ok bool) (V, Op) {
+	return func(current V, ok bool) (V, Op) {
+		if ok && eq(current, old) {
+			*deleted = true
+			return current, OpDelete
+		}
+		return current, OpKeep
+	}
+}
+
+// compute() is the implementation of Compute() and returns also false if the key was evicted
+func (t *Tree[K, V]) compute(key K, fn func(old V, ok bool) (V, Op)) (value V, ok bool, kept bool) {
+	t.lock()
+	expired := t.popExpired()
+	value, ok = t.computeNode(key, fn)
+	changes := t.settle(expired)
+	t.unlock()
+
+	changes.notify()
+	kept = changes.kept(key)
+	return value, ok && kept, kept
+}
+
+// compute() is the implementation of Compute() and returns also false if the key was evicted
+func (t *UnsyncTree[K, V]) compute(key K, fn func(old V, ok bool) (V, Op)) (value V, ok bool, kept bool) {
+	expired := t.popExpired()
+	value, ok = t.computeNode(key, fn)
+	changes := t.settle(expired)
+
+	changes.notify()
+	kept = changes.kept(key)
+	return value, ok && kept, kept
+}
+
+// computeNode() searches the key once, calls fn and applies its result (the tree must be locked)
+// fn is not called for a NaN key, which can't be put
+func (t *core[K, V]) computeNode(key K, fn func(old V, ok bool) (V, Op)) (value V, ok bool) {
+	if isNaN(key) {
+		return value, false
+	}
+	var node, parent *Node[K, V]
+	if t.RootNode != nil {
+		node, parent = t.RootNode.search(key, &t.probe)
+	}
+	var old V
+	if node != nil {
+		old = node.Value
+	}
+
+	value, op := fn(old, node != nil)
+	switch op {
+	case OpPut:
+		t.clearDeadline(key)
+		t.storeAt(node, parent, key, value)
+		return value, true
+	case OpDelete:
+		if node != nil {
+			t.removeNode(node)
+		}
+		var zero V
+		return zero, false
+	default:
+		if node != nil {
+			t.bounds.use(key)
+		}
+		return old, node != nil
+	}
+}
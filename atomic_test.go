@@ -0,0 +1,124 @@
+package avlgo
+
+import "testing"
+
+func TestCompute(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantTree[string, int](v)
+		increment := func(old int, ok bool) (int, Op) {
+			return old + 1, OpPut
+		}
+
+		repeat(v, 100, func() {
+			tree.Compute("counter", increment)
+		})
+		if value, ok := tree.Get("counter"); !ok || value != 100 {
+			t.Errorf("Get returns %d, %v, want 100, true", value, ok)
+		}
+
+		value, ok := tree.Compute("counter", func(old int, ok bool) (int, Op) {
+			return 0, OpDelete
+		})
+		if ok || value != 0 || tree.Size() != 0 {
+			t.Errorf("Compute should delete the key")
+		}
+
+		value, ok = tree.Compute("missing", func(old int, ok bool) (int, Op) {
+			if ok {
+				t.Errorf("Compute shouldn't find the key")
+			}
+			return 10, OpKeep
+		})
+		if ok || value != 0 || tree.Size() != 0 {
+			t.Errorf("Compute shouldn't put anything")
+		}
+	})
+}
+
+func TestGetOrPutAndPutIfAbsent(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantTree[int, string](v)
+		if actual, loaded := tree.GetOrPut(1, "a"); loaded || actual != "a" {
+			t.Errorf("GetOrPut returns %s, %v, want a, false", actual, loaded)
+		}
+		if actual, loaded := tree.GetOrPut(1, "b"); !loaded || actual != "a" {
+			t.Errorf("GetOrPut returns %s, %v, want a, true", actual, loaded)
+		}
+		if !tree.PutIfAbsent(2, "b") {
+			t.Errorf("PutIfAbsent should put 2")
+		}
+		if tree.PutIfAbsent(2, "c") {
+			t.Errorf("PutIfAbsent shouldn't put 2 twice")
+		}
+		if value, _ := tree.Get(2); value != "b" {
+			t.Errorf("Get returns %s, want b", value)
+		}
+
+		bounded := newVariantBoundedTree[int, string](v, 1, EvictSmallest)
+		bounded.PutOne(5, "e")
+		if bounded.PutIfAbsent(1, "a") {
+			t.Errorf("PutIfAbsent should return false for an evicted key")
+		}
+	})
+}
+
+func TestCompareAndSwapAndDelete(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantTree[int, []int](v)
+		eq := func(a, b []int) bool {
+			return len(a) == len(b) && (len(a) == 0 || a[0] == b[0])
+		}
+		tree.PutOne(1, []int{1})
+
+		if tree.CompareAndSwap(1, []int{2}, []int{3}, eq) {
+			t.Errorf("CompareAndSwap shouldn't swap a different value")
+		}
+		if !tree.CompareAndSwap(1, []int{1}, []int{3}, eq) {
+			t.Errorf("CompareAndSwap should swap an equal value")
+		}
+		if tree.CompareAndSwap(2, nil, []int{3}, eq) {
+			t.Errorf("CompareAndSwap shouldn't swap a missing key")
+		}
+		if tree.CompareAndDelete(1, []int{1}, eq) {
+			t.Errorf("CompareAndDelete shouldn't delete a different value")
+		}
+		if !tree.CompareAndDelete(1, []int{3}, eq) {
+			t.Errorf("CompareAndDelete should delete an equal value")
+		}
+		if tree.Size() != 0 {
+			t.Errorf("Tree size is %d, want 0", tree.Size())
+		}
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantTree[string, int](v)
+		increment := func(old int) int {
+			return old + 1
+		}
+		tree.PutOne("counter", 0)
+
+		repeat(v, 100, func() {
+			tree.Update("counter", increment)
+		})
+		if value, ok := tree.Get("counter"); !ok || value != 100 {
+			t.Errorf("Get returns %d, %v, want 100, true", value, ok)
+		}
+		if value, ok := tree.Update("missing", increment); ok || value != 0 {
+			t.Errorf("Update returns %d, %v, want 0, false", value, ok)
+		}
+		if tree.Size() != 1 {
+			t.Errorf("Update shouldn't put a missing key")
+		}
+	})
+
+	sharded := NewShardedTree[int, int]([]int{10, 10}, 0)
+	sharded.PutOne(15, 1)
+	if value, ok := sharded.Update(15, func(old int) int { return old * 10 }); !ok || value != 10 {
+		t.Errorf("ShardedTree.Update returns %d, %v, want 10, true", value, ok)
+	}
+	if _, ok := sharded.Update(5, func(old int) int { return old }); ok {
+		t.Errorf("ShardedTree.Update shouldn't find 5")
+	}
+}
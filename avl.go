@@ -1,5 +1,15 @@
 package avlgo
 
+// Ordered is the constraint of the keys, compared with the < and > operators
+//
+// Float keys follow the IEEE 754 comparisons, with two special cases :
+//   - NaN is not ordered with any value (it is not even equal to itself), so it is rejected as a key :
+//     a put of a NaN key leaves the tree unchanged. The functions returning an error return ErrNaNKey
+//     (Batch(), NewSet(), Set.Add()), the others report that nothing was put : PutOne() and PutWithTTL()
+//     return false, Node.Put() returns the unchanged root node. A NaN key is never found nor deleted.
+//     A range with a NaN bound is empty (the methods taking Bounds return ErrNaNKey)
+//   - -0 and +0 are equal, so they are the same key : a put of one replaces the value of the other,
+//     and the key keeps the sign it was first put with. Both are found by Get(-0) and Get(+0)
 type Ordered interface {
 	Integer | Float | ~string
 }
@@ -15,3 +25,8 @@ type Signed interface {
 type Unsigned interface {
 	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
 }
+
+// isNaN() returns true for a NaN float key (the only value which is not equal to itself)
+func isNaN[K Ordered](key K) bool {
+	return key != key
+}
@@ -0,0 +1,60 @@
+package avlgo
+
+// BatchOp is an operation applied by Batch() : a put of Key/Value, or a delete of Key if Delete is true
+type BatchOp[K Ordered, V any] struct {
+	Key    K
+	Value  V
+	Delete bool
+}
+
+// Batch() applies the operations in order with the tree locked once :
+// the other goroutines see either none or all of them
+// A put removes the TTL of the key, like PutOne(). It returns the number of puts and of deleted keys
+// If an operation has a NaN key, no operation is applied and ErrNaNKey is returned
+// On a bounded tree, the evictions are done once all the operations are applied
+func (t *Tree[K, V]) Batch(ops ...BatchOp[K, V]) (put, deleted int, err error) {
+	if err = validOps(ops); err != nil {
+		return 0, 0, err
+	}
+	t.lock()
+	put, deleted = t.batch(ops)
+	changes := t.settle(nil)
+	t.unlock()
+
+	changes.notify()
+	return put, deleted, nil
+}
+
+// Batch() applies the operations in order, like Tree.Batch()
+func (t *UnsyncTree[K, V]) Batch(ops ...BatchOp[K, V]) (put, deleted int, err error) {
+	if err = validOps(ops); err != nil {
+		return 0, 0, err
+	}
+	put, deleted = t.batch(ops)
+	t.settle(nil).notify()
+	return put, deleted, nil
+}
+
+// validOps() returns ErrNaNKey if an operation has a NaN key
+func validOps[K Ordered, V any](ops []BatchOp[K, V]) error {
+	for _, op := range ops {
+		if isNaN(op.Key) {
+			return ErrNaNKey
+		}
+	}
+	return nil
+}
+
+// batch() applies the operations, whose keys aren't NaN (the tree must be locked)
+func (t *core[K, V]) batch(ops []BatchOp[K, V]) (put, deleted int) {
+	for _, op := range ops {
+		if op.Delete {
+			deleted += t.deleteKeys([]K{op.Key})
+			continue
+		}
+		t.clearDeadline(op.Key)
+		t.putNode(op.Key, op.Value)
+		put++
+	}
+	return put, deleted
+}
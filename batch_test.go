@@ -0,0 +1,94 @@
+package avlgo
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantTree[int, string](v)
+		tree.PutOne(1, "one")
+		tree.PutOne(2, "two")
+
+		var deletes []int
+		tree.OnDelete(func(key int, value string) {
+			deletes = append(deletes, key)
+		})
+
+		put, deleted, err := tree.Batch(
+			BatchOp[int, string]{Key: 3, Value: "three"},
+			BatchOp[int, string]{Key: 1, Delete: true},
+			BatchOp[int, string]{Key: 9, Delete: true},
+			BatchOp[int, string]{Key: 2, Value: "TWO"},
+			BatchOp[int, string]{Key: 3, Delete: true},
+		)
+		if put != 2 || deleted != 2 || err != nil {
+			t.Errorf("Batch returns %d, %d, %v, want 2, 2, nil", put, deleted, err)
+		}
+		if keys, values := tree.PrintKeys(0), tree.PrintValues(0); !reflect.DeepEqual(keys, []int{2}) || !reflect.DeepEqual(values, []string{"TWO"}) {
+			t.Errorf("entries are %v, %v, want [2], [TWO]", keys, values)
+		}
+		if !reflect.DeepEqual(deletes, []int{1, 3}) {
+			t.Errorf("deleted keys are %v, want [1 3]", deletes)
+		}
+		if _, ok := rootOf(tree).isValid(); !ok {
+			t.Errorf("tree should be a valid AVL tree")
+		}
+	})
+}
+
+func TestBatchIsAtomic(t *testing.T) {
+	tree := NewTree[int, int]()
+	ops := make([]BatchOp[int, int], 100)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for round := 1; round <= 50; round++ {
+			for i := range ops {
+				ops[i] = BatchOp[int, int]{Key: i, Value: round}
+			}
+			tree.Batch(ops...)
+		}
+	}()
+
+	//a reader never sees a partially applied batch : all values are equal
+	for i := 0; i < 200; i++ {
+		_, values := entries[int, int](tree)
+		for _, value := range values {
+			if value != values[0] {
+				t.Fatalf("values %v come from several batches", values)
+			}
+		}
+	}
+	wg.Wait()
+}
+
+func TestBatchEviction(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantBoundedTree[int, int](v, 2, EvictSmallest)
+		var evicted []int
+		tree.OnEvict(func(key, value int) {
+			evicted = append(evicted, key)
+		})
+
+		//the evictions are done at the end : 1 is deleted before exceeding the capacity
+		tree.Batch(
+			BatchOp[int, int]{Key: 1},
+			BatchOp[int, int]{Key: 2},
+			BatchOp[int, int]{Key: 3},
+			BatchOp[int, int]{Key: 1, Delete: true},
+		)
+		if len(evicted) != 0 || tree.Size() != 2 {
+			t.Errorf("evicted keys are %v and size is %d, want [] and 2", evicted, tree.Size())
+		}
+
+		tree.Batch(BatchOp[int, int]{Key: 4}, BatchOp[int, int]{Key: 5})
+		if !reflect.DeepEqual(evicted, []int{2, 3}) || tree.Size() != 2 {
+			t.Errorf("evicted keys are %v and size is %d, want [2 3] and 2", evicted, tree.Size())
+		}
+	})
+}
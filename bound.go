@@ -0,0 +1,311 @@
+package avlgo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidRange is returned by the range methods when the lower bound is after the upper bound
+var ErrInvalidRange = errors.New("avlgo: invalid range, the lower bound is after the upper bound")
+
+// ErrNaNKey is returned when a key or a bound is NaN, which can't be ordered with the keys :
+// by the range methods, by Batch(), by NewSet() and by Set.Add()
+var ErrNaNKey = errors.New("avlgo: NaN can't be a key nor a bound")
+
+// boundKind tells how a Bound limits a range
+type boundKind int
+
+const (
+	boundUnbounded boundKind = iota
+	boundInclusive
+	boundExclusive
+)
+
+// Bound is one end of a range of keys : Inclusive(k), Exclusive(k) or Unbounded()
+// The zero value is Unbounded()
+type Bound[K Ordered] struct {
+	key  K
+	kind boundKind
+}
+
+// Inclusive() returns a bound including the key
+func Inclusive[K Ordered](key K) Bound[K] {
+	return Bound[K]{key: key, kind: boundInclusive}
+}
+
+// Exclusive() returns a bound excluding the key
+func Exclusive[K Ordered](key K) Bound[K] {
+	return Bound[K]{key: key, kind: boundExclusive}
+}
+
+// Unbounded() returns a bound which doesn't limit the range (from the start, or to the end)
+func Unbounded[K Ordered]() Bound[K] {
+	return Bound[K]{}
+}
+
+// Key() returns the key of the bound (ok is false for an unbounded bound)
+func (b Bound[K]) Key() (key K, ok bool) {
+	return b.key, b.kind != boundUnbounded
+}
+
+// IsInclusive() returns true for a bound built by Inclusive()
+func (b Bound[K]) IsInclusive() bool {
+	return b.kind == boundInclusive
+}
+
+// IsUnbounded() returns true for a bound built by Unbounded()
+func (b Bound[K]) IsUnbounded() bool {
+	return b.kind == boundUnbounded
+}
+
+// boundsOf() returns the bounds equivalent to the (from, to, boundsIncluded) arguments of GetFromTo()
+func boundsOf[K Ordered](from, to K, boundsIncluded bool) (lo, hi Bound[K]) {
+	if boundsIncluded {
+		return Inclusive(from), Inclusive(to)
+	}
+	return Exclusive(from), Exclusive(to)
+}
+
+// validRange() returns ErrInvalidRange if the lower bound lo is after the upper bound hi, ErrNaNKey if a bound is NaN
+// An empty range, like [k, k), is valid
+func validRange[K Ordered](lo, hi Bound[K]) error {
+	if (lo.kind != boundUnbounded && isNaN(lo.key)) || (hi.kind != boundUnbounded && isNaN(hi.key)) {
+		return ErrNaNKey
+	}
+	if lo.kind != boundUnbounded && hi.kind != boundUnbounded && lo.key > hi.key {
+		return ErrInvalidRange
+	}
+	return nil
+}
+
+// admitsAbove() returns true if the key is after the lower bound b
+func (b Bound[K]) admitsAbove(key K) bool {
+	switch b.kind {
+	case boundInclusive:
+		return key >= b.key
+	case boundExclusive:
+		return key > b.key
+	default:
+		return true
+	}
+}
+
+// admitsBelow() returns true if the key is before the upper bound b
+func (b Bound[K]) admitsBelow(key K) bool {
+	switch b.kind {
+	case boundInclusive:
+		return key <= b.key
+	case boundExclusive:
+		return key < b.key
+	default:
+		return true
+	}
+}
+
+// GetRange() returns the ordered entries whose keys are between the bounds lo and hi
+func (t *Tree[K, V]) GetRange(lo, hi Bound[K]) (entries []Entry[K, V], err error) {
+	err = t.AscendRange(lo, hi, appendEntry(&entries))
+	return entries, err
+}
+
+// GetRange() returns the ordered entries whose keys are between the bounds lo and hi
+func (t *UnsyncTree[K, V]) GetRange(lo, hi Bound[K]) (entries []Entry[K, V], err error) {
+	err = t.AscendRange(lo, hi, appendEntry(&entries))
+	return entries, err
+}
+
+// CountRange() returns the number of keys between the bounds lo and hi
+func (t *Tree[K, V]) CountRange(lo, hi Bound[K]) (count int, err error) {
+	err = t.AscendRange(lo, hi, countEntry[K, V](&count))
+	return count, err
+}
+
+// CountRange() returns the number of keys between the bounds lo and hi
+func (t *UnsyncTree[K, V]) CountRange(lo, hi Bound[K]) (count int, err error) {
+	err = t.AscendRange(lo, hi, countEntry[K, V](&count))
+	return count, err
+}
+
+// appendEntry() and countEntry() return the functions given to AscendRange() by GetRange() and CountRange()
+func appendEntry[K Ordered, V any](entries *[]Entry[K, V]) func(key K, value V) bool {
+	return func(key K, value V) bool {
+		*entries = append(*entries, Entry[K, V]{Key: key, Value: value})
+		return true
+	}
+}
+
+func countEntry[K Ordered, V any](count *int) func(key K, value V) bool {
+	return func(key K, value V) bool {
+		*count++
+		return true
+	}
+}
+
+// AscendRange() calls fn for each key/value between the bounds lo and hi, in order, until fn returns false
+// The tree is read-locked during the walk, so fn must not modify it
+func (t *Tree[K, V]) AscendRange(lo, hi Bound[K], fn func(key K, value V) bool) error {
+	if err := validRange(lo, hi); err != nil {
+		return err
+	}
+	t.rlockLive()
+	defer t.runlock()
+	t.ascend(lo, hi, fn)
+	return nil
+}
+
+// AscendRange() calls fn for each key/value between the bounds lo and hi, in order, until fn returns false
+// fn must not modify the tree
+func (t *UnsyncTree[K, V]) AscendRange(lo, hi Bound[K], fn func(key K, value V) bool) error {
+	if err := validRange(lo, hi); err != nil {
+		return err
+	}
+	t.removeExpired()
+	t.ascend(lo, hi, fn)
+	return nil
+}
+
+// GetRangePage() acts like GetFromToPage() for the keys between the bounds lo and hi
+func (t *Tree[K, V]) GetRangePage(lo, hi Bound[K], limit int, cursor string) (entries []Entry[K, V], next string, err error) {
+	return getRangePage(t.AscendRange, lo, hi, limit, cursor)
+}
+
+// GetRangePage() acts like GetFromToPage() for the keys between the bounds lo and hi
+func (t *UnsyncTree[K, V]) GetRangePage(lo, hi Bound[K], limit int, cursor string) (entries []Entry[K, V], next string, err error) {
+	return getRangePage(t.AscendRange, lo, hi, limit, cursor)
+}
+
+// getRangePage() is the implementation of GetRangePage(), walking the range with ascendRange
+func getRangePage[K Ordered, V any](ascendRange func(lo, hi Bound[K], fn func(key K, value V) bool) error,
+	lo, hi Bound[K], limit int, cursor string) (entries []Entry[K, V], next string, err error) {
+	if err = validRange(lo, hi); err != nil {
+		return nil, "", err
+	}
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("unable to get page : limit must be positive, got %d", limit)
+	}
+	if cursor != "" {
+		after, err := decodeCursor[K](cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		if isNaN(after) {
+			return nil, "", ErrNaNKey
+		}
+		if lo.admitsAbove(after) { //a cursor before the range starts at lo
+			lo = Exclusive(after)
+		}
+	}
+
+	more := false
+	err = ascendRange(lo, hi, func(key K, value V) bool {
+		if len(entries) == limit {
+			more = true
+			return false
+		}
+		entries = append(entries, Entry[K, V]{Key: key, Value: value})
+		return true
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if more {
+		next = encodeCursor(entries[len(entries)-1].Key)
+	}
+	return entries, next, nil
+}
+
+// GetRange() returns the ordered entries whose keys are between the bounds lo and hi
+func (st *ShardedTree[K, V]) GetRange(lo, hi Bound[K]) (entries []Entry[K, V], err error) {
+	err = st.AscendRange(lo, hi, func(key K, value V) bool {
+		entries = append(entries, Entry[K, V]{Key: key, Value: value})
+		return true
+	})
+	return entries, err
+}
+
+// CountRange() returns the number of keys between the bounds lo and hi
+func (st *ShardedTree[K, V]) CountRange(lo, hi Bound[K]) (count int, err error) {
+	err = st.AscendRange(lo, hi, func(key K, value V) bool {
+		count++
+		return true
+	})
+	return count, err
+}
+
+// AscendRange() calls fn for each key/value between the bounds lo and hi, in order, until fn returns false
+// Each shard is read-locked while it is walked, so fn must not modify the tree
+func (st *ShardedTree[K, V]) AscendRange(lo, hi Bound[K], fn func(key K, value V) bool) error {
+	if err := validRange(lo, hi); err != nil {
+		return err
+	}
+	st.ascend(lo, hi, func(node *Node[K, V]) bool {
+		return fn(node.Key, node.Value)
+	})
+	return nil
+}
+
+// DeleteRange() removes the keys between the bounds lo and hi and returns the number of removed keys, like Extract()
+func (st *ShardedTree[K, V]) DeleteRange(lo, hi Bound[K]) (int, error) {
+	removed, err := st.Extract(lo, hi)
+	if err != nil {
+		return 0, err
+	}
+	return removed.count, nil
+}
+
+// GetRangePage() returns, in order, at most limit entries whose keys are between the bounds lo and hi, like Tree.GetRangePage()
+func (st *ShardedTree[K, V]) GetRangePage(lo, hi Bound[K], limit int, cursor string) (entries []Entry[K, V], next string, err error) {
+	return getRangePage(st.AscendRange, lo, hi, limit, cursor)
+}
+
+// GetRange() returns the ordered entries whose keys are between the bounds lo and hi, from one version of the tree
+func (t *RCUTree[K, V]) GetRange(lo, hi Bound[K]) (entries []Entry[K, V], err error) {
+	err = t.AscendRange(lo, hi, func(key K, value V) bool {
+		entries = append(entries, Entry[K, V]{Key: key, Value: value})
+		return true
+	})
+	return entries, err
+}
+
+// CountRange() returns the number of keys between the bounds lo and hi, in one version of the tree
+func (t *RCUTree[K, V]) CountRange(lo, hi Bound[K]) (count int, err error) {
+	err = t.AscendRange(lo, hi, func(key K, value V) bool {
+		count++
+		return true
+	})
+	return count, err
+}
+
+// AscendRange() calls fn for each key/value between the bounds lo and hi of one version of the tree,
+// in order, until fn returns false
+func (t *RCUTree[K, V]) AscendRange(lo, hi Bound[K], fn func(key K, value V) bool) error {
+	if err := validRange(lo, hi); err != nil {
+		return err
+	}
+	t.root.Load().node.ascend(lo, hi, fn)
+	return nil
+}
+
+// DeleteRange() removes the keys between the bounds lo and hi at once and returns the number of removed keys
+func (t *RCUTree[K, V]) DeleteRange(lo, hi Bound[K]) (int, error) {
+	if err := validRange(lo, hi); err != nil {
+		return 0, err
+	}
+	t.writeMutex.Lock()
+	defer t.writeMutex.Unlock()
+
+	root := t.root.Load()
+	keys := make([]K, 0)
+	root.node.ascend(lo, hi, func(key K, value V) bool {
+		keys = append(keys, key)
+		return true
+	})
+	node := root.node
+	for _, k := range keys {
+		node, _ = node.delete(k)
+	}
+	if len(keys) > 0 {
+		t.root.Store(&rcuRoot[K, V]{node: node, size: root.size - len(keys)})
+	}
+	return len(keys), nil
+}
@@ -0,0 +1,170 @@
+package avlgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func rangeKeys(entries []Entry[int, int]) []int {
+	keys := make([]int, 0, len(entries))
+	for _, e := range entries {
+		keys = append(keys, e.Key)
+	}
+	return keys
+}
+
+func TestGetRange(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantTree[int, int](v)
+		for i := 0; i < 10; i++ {
+			tree.PutOne(i, i)
+		}
+
+		tests := []struct {
+			name   string
+			lo, hi Bound[int]
+			want   []int
+		}{
+			{"[2, 5]", Inclusive(2), Inclusive(5), []int{2, 3, 4, 5}},
+			{"[2, 5)", Inclusive(2), Exclusive(5), []int{2, 3, 4}},
+			{"(2, 5]", Exclusive(2), Inclusive(5), []int{3, 4, 5}},
+			{"(2, 5)", Exclusive(2), Exclusive(5), []int{3, 4}},
+			{"(-inf, 3)", Unbounded[int](), Exclusive(3), []int{0, 1, 2}},
+			{"[7, +inf)", Inclusive(7), Unbounded[int](), []int{7, 8, 9}},
+			{"(-inf, +inf)", Unbounded[int](), Unbounded[int](), []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+			{"[4, 4)", Inclusive(4), Exclusive(4), []int{}},
+			{"[4, 4]", Inclusive(4), Inclusive(4), []int{4}},
+			{"(-5, 20)", Exclusive(-5), Exclusive(20), []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		}
+		for _, test := range tests {
+			entries, err := tree.GetRange(test.lo, test.hi)
+			if err != nil {
+				t.Fatalf("GetRange%s shouldn't return an error. %s is returned", test.name, err)
+			}
+			if got := rangeKeys(entries); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("GetRange%s is %v, want %v", test.name, got, test.want)
+			}
+			count, _ := tree.CountRange(test.lo, test.hi)
+			if count != len(test.want) {
+				t.Errorf("CountRange%s is %d, want %d", test.name, count, len(test.want))
+			}
+		}
+
+		if _, err := tree.GetRange(Inclusive(5), Inclusive(2)); err != ErrInvalidRange {
+			t.Errorf("GetRange[5, 2] should return ErrInvalidRange. %v is returned", err)
+		}
+		if _, err := tree.CountRange(Exclusive(5), Exclusive(2)); err != ErrInvalidRange {
+			t.Errorf("CountRange(5, 2) should return ErrInvalidRange. %v is returned", err)
+		}
+		if _, err := tree.DeleteRange(Inclusive(5), Exclusive(2)); err != ErrInvalidRange {
+			t.Errorf("DeleteRange[5, 2) should return ErrInvalidRange. %v is returned", err)
+		}
+		if err := tree.AscendRange(Inclusive(5), Inclusive(2), func(int, int) bool { return true }); err != ErrInvalidRange {
+			t.Errorf("AscendRange[5, 2] should return ErrInvalidRange. %v is returned", err)
+		}
+	})
+}
+
+func TestDeleteRange(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantTree[int, int](v)
+		for i := 0; i < 100; i++ {
+			tree.PutOne(i, i)
+		}
+
+		if deleted, _ := tree.DeleteRange(Exclusive(10), Inclusive(20)); deleted != 10 {
+			t.Errorf("DeleteRange(10, 20] deleted %d keys, want 10", deleted)
+		}
+		if deleted, _ := tree.DeleteRange(Inclusive(90), Unbounded[int]()); deleted != 10 {
+			t.Errorf("DeleteRange[90, +inf) deleted %d keys, want 10", deleted)
+		}
+		if deleted, _ := tree.DeleteRange(Unbounded[int](), Exclusive(5)); deleted != 5 {
+			t.Errorf("DeleteRange(-inf, 5) deleted %d keys, want 5", deleted)
+		}
+		if tree.Size() != 75 {
+			t.Errorf("size is %d, want 75", tree.Size())
+		}
+		for _, k := range []int{4, 11, 20, 90, 99} {
+			if _, ok := tree.Get(k); ok {
+				t.Errorf("%d should have been deleted", k)
+			}
+		}
+		for _, k := range []int{5, 10, 21, 89} {
+			if _, ok := tree.Get(k); !ok {
+				t.Errorf("%d shouldn't have been deleted", k)
+			}
+		}
+		if _, ok := rootOf(tree).isValid(); !ok {
+			t.Errorf("tree isn't a valid AVL tree after DeleteRange")
+		}
+	})
+}
+
+func TestGetRangePage(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantTree[int, int](v)
+		for i := 0; i < 20; i++ {
+			tree.PutOne(i, i)
+		}
+
+		keys := make([]int, 0)
+		cursor := ""
+		for {
+			entries, next, err := tree.GetRangePage(Exclusive(3), Unbounded[int](), 4, cursor)
+			if err != nil {
+				t.Fatalf("GetRangePage shouldn't return an error. %s is returned", err)
+			}
+			keys = append(keys, rangeKeys(entries)...)
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+		if want := []int{4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19}; !reflect.DeepEqual(keys, want) {
+			t.Errorf("paged keys are %v, want %v", keys, want)
+		}
+	})
+}
+
+func TestRangeShardedAndRCU(t *testing.T) {
+	sharded := NewShardedTree[int, int]([]int{10, 20}, 0)
+	rcu := NewRCUTree[int, int]()
+	for i := 0; i < 30; i++ {
+		sharded.PutOne(i, i)
+		rcu.PutOne(i, i)
+	}
+
+	want := []int{10, 11, 12, 13, 14, 15, 16, 17, 18, 19}
+	entries, _ := sharded.GetRange(Inclusive(10), Exclusive(20))
+	if got := rangeKeys(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("ShardedTree.GetRange[10, 20) is %v, want %v", got, want)
+	}
+	entries, _ = rcu.GetRange(Inclusive(10), Exclusive(20))
+	if got := rangeKeys(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("RCUTree.GetRange[10, 20) is %v, want %v", got, want)
+	}
+
+	if count, _ := sharded.CountRange(Exclusive(5), Unbounded[int]()); count != 24 {
+		t.Errorf("ShardedTree.CountRange(5, +inf) is %d, want 24", count)
+	}
+	if count, _ := rcu.CountRange(Unbounded[int](), Inclusive(5)); count != 6 {
+		t.Errorf("RCUTree.CountRange(-inf, 5] is %d, want 6", count)
+	}
+
+	if deleted, _ := sharded.DeleteRange(Exclusive(15), Inclusive(25)); deleted != 10 {
+		t.Errorf("ShardedTree.DeleteRange(15, 25] deleted %d keys, want 10", deleted)
+	}
+	if deleted, _ := rcu.DeleteRange(Exclusive(15), Inclusive(25)); deleted != 10 {
+		t.Errorf("RCUTree.DeleteRange(15, 25] deleted %d keys, want 10", deleted)
+	}
+	if sharded.Size() != 20 || rcu.Size() != 20 {
+		t.Errorf("sizes are %d and %d, want 20", sharded.Size(), rcu.Size())
+	}
+
+	if _, err := sharded.GetRange(Inclusive(3), Exclusive(1)); err != ErrInvalidRange {
+		t.Errorf("ShardedTree.GetRange[3, 1) should return ErrInvalidRange. %v is returned", err)
+	}
+	if _, err := rcu.GetRange(Inclusive(3), Exclusive(1)); err != ErrInvalidRange {
+		t.Errorf("RCUTree.GetRange[3, 1) should return ErrInvalidRange. %v is returned", err)
+	}
+}
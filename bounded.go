@@ -0,0 +1,114 @@
+package avlgo
+
+import "container/list"
+
+// EvictionPolicy tells a bounded Tree which entry to evict when its capacity is exceeded
+type EvictionPolicy int
+
+const (
+	EvictSmallest EvictionPolicy = iota // evict the entry with the smallest key
+	EvictLargest                        // evict the entry with the largest key
+	EvictLRU                            // evict the least recently used entry (put or got)
+)
+
+// bounds holds the capacity and the eviction state of a bounded Tree
+type bounds[K Ordered, V any] struct {
+	maxEntries int                  //maximum number of entries in the tree
+	policy     EvictionPolicy       //which entry to evict
+	onEvict    func(key K, value V) //called for every evicted entry
+
+	recency  *list.List          //keys from the most recently used (front) to the least (back), for EvictLRU
+	elements map[K]*list.Element //element of each key in the recency list, for EvictLRU
+}
+
+// NewBoundedTree() returns an empty new Tree holding at most maxEntries entries
+// A put exceeding the capacity evicts one entry, chosen by the policy
+// maxEntries must be positive
+func NewBoundedTree[K Ordered, V any](maxEntries int, policy EvictionPolicy) *Tree[K, V] {
+	return &Tree[K, V]{core: core[K, V]{bounds: newBounds[K, V](maxEntries, policy)}}
+}
+
+// NewUnsyncBoundedTree() returns an empty new UnsyncTree holding at most maxEntries entries, like NewBoundedTree()
+func NewUnsyncBoundedTree[K Ordered, V any](maxEntries int, policy EvictionPolicy) *UnsyncTree[K, V] {
+	return &UnsyncTree[K, V]{core: core[K, V]{bounds: newBounds[K, V](maxEntries, policy)}}
+}
+
+// newBounds() returns the bounds of a new bounded tree
+func newBounds[K Ordered, V any](maxEntries int, policy EvictionPolicy) *bounds[K, V] {
+	if maxEntries < 1 {
+		panic("avlgo: NewBoundedTree() needs a positive maxEntries")
+	}
+	b := &bounds[K, V]{maxEntries: maxEntries, policy: policy}
+	if policy == EvictLRU {
+		b.recency = list.New()
+		b.elements = make(map[K]*list.Element)
+	}
+	return b
+}
+
+// OnEvict() registers a callback called (outside of the lock) for every entry evicted by a bounded tree
+func (t *Tree[K, V]) OnEvict(fn func(key K, value V)) {
+	t.lock()
+	defer t.unlock()
+	t.onEvict(fn)
+}
+
+// OnEvict() registers a callback called for every entry evicted by a bounded tree
+func (t *UnsyncTree[K, V]) OnEvict(fn func(key K, value V)) {
+	t.onEvict(fn)
+}
+
+func (t *core[K, V]) onEvict(fn func(key K, value V)) {
+	if t.bounds != nil {
+		t.bounds.onEvict = fn
+	}
+}
+
+// evict() removes the entries exceeding the capacity and returns them (the tree must be locked)
+func (t *core[K, V]) evict() (evicted []*Node[K, V]) {
+	if t.bounds == nil {
+		return nil
+	}
+	for t.count > t.bounds.maxEntries {
+		var victim *Node[K, V]
+		switch t.bounds.policy {
+		case EvictLargest:
+			victim = t.RootNode.max()
+		case EvictLRU:
+			victim = t.RootNode.Get(t.bounds.recency.Back().Value.(K))
+		default:
+			victim = t.RootNode.min()
+		}
+		t.removeNode(victim)
+		evicted = append(evicted, victim)
+	}
+	return evicted
+}
+
+// tracksRecency() returns true if the gets change the state of the bounds : b evicts the least recently used entry
+func (b *bounds[K, V]) tracksRecency() bool {
+	return b != nil && b.policy == EvictLRU
+}
+
+// use() marks the key as the most recently used (b may be nil)
+func (b *bounds[K, V]) use(key K) {
+	if !b.tracksRecency() {
+		return
+	}
+	if element, ok := b.elements[key]; ok {
+		b.recency.MoveToFront(element)
+	} else {
+		b.elements[key] = b.recency.PushFront(key)
+	}
+}
+
+// remove() forgets a removed key (b may be nil)
+func (b *bounds[K, V]) remove(key K) {
+	if !b.tracksRecency() {
+		return
+	}
+	if element, ok := b.elements[key]; ok {
+		b.recency.Remove(element)
+		delete(b.elements, key)
+	}
+}
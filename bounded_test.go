@@ -0,0 +1,82 @@
+package avlgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBoundedTreeEvictSmallest(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantBoundedTree[int, int](v, 3, EvictSmallest)
+		evicted := make([]int, 0)
+		tree.OnEvict(func(key, value int) {
+			evicted = append(evicted, key)
+		})
+
+		for _, k := range []int{5, 3, 8, 1, 9, 3} {
+			tree.PutOne(k, k)
+		}
+		//1 is smaller than every kept key, so it's evicted as soon as it's put
+		keys := tree.PrintKeys(0)
+		if !reflect.DeepEqual(keys, []int{5, 8, 9}) {
+			t.Errorf("keys is %v, want %v", keys, []int{5, 8, 9})
+		}
+		if !reflect.DeepEqual(evicted, []int{1, 3, 3}) {
+			t.Errorf("evicted keys are %v, want %v", evicted, []int{1, 3, 3})
+		}
+		if tree.PutOne(2, 2) {
+			t.Errorf("PutOne should return false for an evicted key")
+		}
+		if !tree.PutOne(10, 10) {
+			t.Errorf("PutOne should return true for a kept key")
+		}
+		//replacing a value doesn't evict anything
+		tree.PutOne(10, 100)
+		if tree.Size() != 3 {
+			t.Errorf("Tree size is %d, want 3", tree.Size())
+		}
+	})
+}
+
+func TestBoundedTreeEvictLargest(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantBoundedTree[int, int](v, 3, EvictLargest)
+		for i := 0; i < 10; i++ {
+			tree.PutOne(9-i, i)
+		}
+		keys := tree.PrintKeys(0)
+		if !reflect.DeepEqual(keys, []int{0, 1, 2}) {
+			t.Errorf("keys is %v, want %v", keys, []int{0, 1, 2})
+		}
+
+		//deleting frees some room
+		tree.Delete(0, 1)
+		tree.PutOne(8, 8)
+		tree.PutOne(7, 7)
+		keys = tree.PrintKeys(0)
+		if !reflect.DeepEqual(keys, []int{2, 7, 8}) {
+			t.Errorf("keys is %v, want %v", keys, []int{2, 7, 8})
+		}
+	})
+}
+
+func TestBoundedTreeEvictLRU(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantBoundedTree[string, int](v, 3, EvictLRU)
+		tree.PutOne("a", 1)
+		tree.PutOne("b", 2)
+		tree.PutOne("c", 3)
+		tree.Get("a")       //b is now the least recently used
+		tree.PutOne("d", 4) //evicts b
+		tree.PutOne("c", 5) //a is now the least recently used
+		tree.PutOne("e", 6) //evicts a
+
+		keys := tree.PrintKeys(0)
+		if !reflect.DeepEqual(keys, []string{"c", "d", "e"}) {
+			t.Errorf("keys is %v, want %v", keys, []string{"c", "d", "e"})
+		}
+		if coreOf(tree).bounds.recency.Len() != 3 || len(coreOf(tree).bounds.elements) != 3 {
+			t.Errorf("the recency list should hold 3 keys")
+		}
+	})
+}
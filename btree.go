@@ -0,0 +1,386 @@
+package avlgo
+
+import (
+	"sort"
+	"sync"
+)
+
+// DefaultBTreeDegree is the degree of a BTree built with a degree smaller than 2
+const DefaultBTreeDegree = 16
+
+// BTree is a B-tree implementing SortedMap
+// Each node holds between degree-1 and 2*degree-1 sorted keys (the root may hold fewer) :
+// a search compares more keys per node but follows far fewer pointers than in a binary tree,
+// which makes the ordered walks and the lookups in large trees cache friendly
+// Like a Tree, it is safe for concurrent use : reads take a read lock, writes a lock
+type BTree[K Ordered, V any] struct {
+	rwMutex sync.RWMutex
+	root    *bNode[K, V]
+	degree  int
+	size    int
+}
+
+// bNode is a node of a BTree. An inner node has one more child than keys, a leaf has no child
+type bNode[K Ordered, V any] struct {
+	keys     []K
+	values   []V
+	children []*bNode[K, V]
+}
+
+// NewBTree() returns an empty new BTree of the degree (DefaultBTreeDegree if degree is smaller than 2)
+func NewBTree[K Ordered, V any](degree int) *BTree[K, V] {
+	if degree < 2 {
+		degree = DefaultBTreeDegree
+	}
+	return &BTree[K, V]{degree: degree}
+}
+
+// Size() returns the number of entries of the tree
+func (t *BTree[K, V]) Size() int {
+	t.rwMutex.RLock()
+	defer t.rwMutex.RUnlock()
+	return t.size
+}
+
+// Get() returns the value present in the tree for the key
+func (t *BTree[K, V]) Get(key K) (value V, ok bool) {
+	t.rwMutex.RLock()
+	defer t.rwMutex.RUnlock()
+
+	n := t.root
+	for n != nil {
+		i, found := n.find(key)
+		if found {
+			return n.values[i], true
+		}
+		if n.isLeaf() {
+			break
+		}
+		n = n.children[i]
+	}
+	return value, false
+}
+
+// GetFromTo() returns an ordered slice of values for keys found between from and to (including bounds or not)
+func (t *BTree[K, V]) GetFromTo(from, to K, boundsIncluded bool) (values []V) {
+	t.AscendFromTo(from, to, boundsIncluded, func(key K, value V) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+// Ascend() calls fn for each key/value of the tree, in order, until fn returns false
+// The tree is read-locked during the walk, so fn must not modify it
+func (t *BTree[K, V]) Ascend(fn func(key K, value V) bool) {
+	t.rwMutex.RLock()
+	defer t.rwMutex.RUnlock()
+	if t.root != nil {
+		t.root.ascend(Unbounded[K](), Unbounded[K](), fn)
+	}
+}
+
+// AscendFromTo() acts like Ascend() for the keys between from and to (including bounds or not)
+func (t *BTree[K, V]) AscendFromTo(from, to K, boundsIncluded bool, fn func(key K, value V) bool) {
+	t.rwMutex.RLock()
+	defer t.rwMutex.RUnlock()
+	if t.root != nil {
+		lo, hi := boundsOf(from, to, boundsIncluded)
+		t.root.ascend(lo, hi, fn)
+	}
+}
+
+// PutOne() adds one element in the tree, replacing the value if the key is already present
+// A full node met on the way down is split first, so that the insertion never goes back up
+// A NaN key is not put : it returns false
+func (t *BTree[K, V]) PutOne(key K, value V) bool {
+	if isNaN(key) {
+		return false
+	}
+	t.rwMutex.Lock()
+	defer t.rwMutex.Unlock()
+
+	if t.root == nil {
+		t.root = &bNode[K, V]{keys: []K{key}, values: []V{value}}
+		t.size++
+		return true
+	}
+	if len(t.root.keys) == 2*t.degree-1 {
+		t.root = &bNode[K, V]{children: []*bNode[K, V]{t.root}}
+		t.root.splitChild(0, t.degree)
+	}
+	if t.root.put(key, value, t.degree) {
+		t.size++
+	}
+	return true
+}
+
+// Delete() removes the entries of the passed keys and returns the number of entries deleted
+// A node with the minimal number of keys met on the way down is filled first, so that the removal never goes back up
+func (t *BTree[K, V]) Delete(keys ...K) int {
+	t.rwMutex.Lock()
+	defer t.rwMutex.Unlock()
+
+	deleted := 0
+	for _, k := range keys {
+		if t.root == nil {
+			break
+		}
+		if isNaN(k) {
+			continue
+		}
+		if t.root.delete(k, t.degree) {
+			t.size--
+			deleted++
+		}
+		if len(t.root.keys) == 0 { //the root was emptied by a merge of its children, or by the removal of the last key
+			if t.root.isLeaf() {
+				t.root = nil
+			} else {
+				t.root = t.root.children[0]
+			}
+		}
+	}
+	return deleted
+}
+
+// isValid() checks the order of the keys, the number of keys of each node and that all the leaves have the same depth
+func (t *BTree[K, V]) isValid() bool {
+	t.rwMutex.RLock()
+	defer t.rwMutex.RUnlock()
+	if t.root == nil {
+		return t.size == 0
+	}
+	_, ok := t.root.check(Unbounded[K](), Unbounded[K](), t.degree, true)
+	return ok
+}
+
+// isLeaf() returns true for a node without children
+func (n *bNode[K, V]) isLeaf() bool {
+	return len(n.children) == 0
+}
+
+// find() returns the index of the first key of the node bigger or equal to key, and true if it is equal
+func (n *bNode[K, V]) find(key K) (int, bool) {
+	i := sort.Search(len(n.keys), func(i int) bool { return n.keys[i] >= key })
+	return i, i < len(n.keys) && n.keys[i] == key
+}
+
+// ascend() calls fn for the key/values of the subtree between the bounds, in order,
+// and returns false if the walk must stop (fn returned false, or a key after hi was met)
+func (n *bNode[K, V]) ascend(lo, hi Bound[K], fn func(key K, value V) bool) bool {
+	start := 0
+	if lo.kind != boundUnbounded {
+		start, _ = n.find(lo.key)
+	}
+	for i := start; i < len(n.keys); i++ {
+		if !n.isLeaf() && !n.children[i].ascend(lo, hi, fn) {
+			return false
+		}
+		if !hi.admitsBelow(n.keys[i]) {
+			return false
+		}
+		if lo.admitsAbove(n.keys[i]) && !fn(n.keys[i], n.values[i]) {
+			return false
+		}
+	}
+	if !n.isLeaf() {
+		return n.children[len(n.keys)].ascend(lo, hi, fn)
+	}
+	return true
+}
+
+// put() puts the key/value in the subtree of a node which is not full and returns true if the key was added
+func (n *bNode[K, V]) put(key K, value V, degree int) bool {
+	for {
+		i, found := n.find(key)
+		if found {
+			n.values[i] = value
+			return false
+		}
+		if n.isLeaf() {
+			n.keys = insertAt(n.keys, i, key)
+			n.values = insertAt(n.values, i, value)
+			return true
+		}
+		if len(n.children[i].keys) == 2*degree-1 {
+			n.splitChild(i, degree)
+			switch {
+			case key == n.keys[i]: //the key was the median of the child
+				n.values[i] = value
+				return false
+			case key > n.keys[i]:
+				i++
+			}
+		}
+		n = n.children[i]
+	}
+}
+
+// splitChild() splits the full child i in two nodes of degree-1 keys, moving up its median key
+func (n *bNode[K, V]) splitChild(i, degree int) {
+	child := n.children[i]
+	next := &bNode[K, V]{
+		keys:   append([]K(nil), child.keys[degree:]...),
+		values: append([]V(nil), child.values[degree:]...),
+	}
+	if !child.isLeaf() {
+		next.children = append([]*bNode[K, V](nil), child.children[degree:]...)
+		child.children = truncate(child.children, degree)
+	}
+	n.keys = insertAt(n.keys, i, child.keys[degree-1])
+	n.values = insertAt(n.values, i, child.values[degree-1])
+	n.children = insertAt(n.children, i+1, next)
+	child.keys = truncate(child.keys, degree-1)
+	child.values = truncate(child.values, degree-1)
+}
+
+// delete() removes the key from the subtree of a node holding at least degree keys (or of the root)
+// and returns true if the key was found
+func (n *bNode[K, V]) delete(key K, degree int) bool {
+	i, found := n.find(key)
+	if n.isLeaf() {
+		if !found {
+			return false
+		}
+		n.keys = removeAt(n.keys, i)
+		n.values = removeAt(n.values, i)
+		return true
+	}
+
+	if found {
+		//replace the key by its predecessor or its successor, from a child which can lose a key,
+		//or merge both children around the key and remove it from the merged node
+		switch {
+		case len(n.children[i].keys) >= degree:
+			predecessor := n.children[i]
+			for !predecessor.isLeaf() {
+				predecessor = predecessor.children[len(predecessor.children)-1]
+			}
+			last := len(predecessor.keys) - 1
+			n.keys[i], n.values[i] = predecessor.keys[last], predecessor.values[last]
+			return n.children[i].delete(n.keys[i], degree)
+		case len(n.children[i+1].keys) >= degree:
+			successor := n.children[i+1]
+			for !successor.isLeaf() {
+				successor = successor.children[0]
+			}
+			n.keys[i], n.values[i] = successor.keys[0], successor.values[0]
+			return n.children[i+1].delete(n.keys[i], degree)
+		default:
+			n.merge(i)
+			return n.children[i].delete(key, degree)
+		}
+	}
+
+	if len(n.children[i].keys) < degree {
+		i = n.fill(i, degree)
+	}
+	return n.children[i].delete(key, degree)
+}
+
+// fill() gives at least degree keys to the child i, borrowing a key from a sibling or merging it with a sibling,
+// and returns the index of the child holding its keys
+func (n *bNode[K, V]) fill(i, degree int) int {
+	switch {
+	case i > 0 && len(n.children[i-1].keys) >= degree:
+		child, previous := n.children[i], n.children[i-1]
+		last := len(previous.keys) - 1
+		child.keys = insertAt(child.keys, 0, n.keys[i-1])
+		child.values = insertAt(child.values, 0, n.values[i-1])
+		n.keys[i-1], n.values[i-1] = previous.keys[last], previous.values[last]
+		if !child.isLeaf() {
+			child.children = insertAt(child.children, 0, previous.children[last+1])
+			previous.children = truncate(previous.children, last+1)
+		}
+		previous.keys = truncate(previous.keys, last)
+		previous.values = truncate(previous.values, last)
+		return i
+	case i < len(n.keys) && len(n.children[i+1].keys) >= degree:
+		child, next := n.children[i], n.children[i+1]
+		child.keys = append(child.keys, n.keys[i])
+		child.values = append(child.values, n.values[i])
+		n.keys[i], n.values[i] = next.keys[0], next.values[0]
+		if !child.isLeaf() {
+			child.children = append(child.children, next.children[0])
+			next.children = removeAt(next.children, 0)
+		}
+		next.keys = removeAt(next.keys, 0)
+		next.values = removeAt(next.values, 0)
+		return i
+	case i < len(n.keys):
+		n.merge(i)
+		return i
+	default:
+		n.merge(i - 1)
+		return i - 1
+	}
+}
+
+// merge() merges the child i, the key i and the child i+1 in the child i
+func (n *bNode[K, V]) merge(i int) {
+	child, next := n.children[i], n.children[i+1]
+	child.keys = append(append(child.keys, n.keys[i]), next.keys...)
+	child.values = append(append(child.values, n.values[i]), next.values...)
+	child.children = append(child.children, next.children...)
+	n.keys = removeAt(n.keys, i)
+	n.values = removeAt(n.values, i)
+	n.children = removeAt(n.children, i+1)
+}
+
+// check() checks the subtree, whose keys must be between the bounds, and returns its depth
+func (n *bNode[K, V]) check(lo, hi Bound[K], degree int, isRoot bool) (depth int, ok bool) {
+	if len(n.keys) > 2*degree-1 || len(n.keys) != len(n.values) || (!isRoot && len(n.keys) < degree-1) || len(n.keys) == 0 {
+		return 0, false
+	}
+	for i, key := range n.keys {
+		if !lo.admitsAbove(key) || !hi.admitsBelow(key) || (i > 0 && n.keys[i-1] >= key) {
+			return 0, false
+		}
+	}
+	if n.isLeaf() {
+		return 1, true
+	}
+	if len(n.children) != len(n.keys)+1 {
+		return 0, false
+	}
+	for i, child := range n.children {
+		childLo, childHi := lo, hi
+		if i > 0 {
+			childLo = Exclusive(n.keys[i-1])
+		}
+		if i < len(n.keys) {
+			childHi = Exclusive(n.keys[i])
+		}
+		childDepth, ok := child.check(childLo, childHi, degree, false)
+		if !ok || (i > 0 && childDepth != depth) {
+			return 0, false
+		}
+		depth = childDepth
+	}
+	return depth + 1, true
+}
+
+// insertAt() inserts v at the index i of s
+func insertAt[T any](s []T, i int, v T) []T {
+	var zero T
+	s = append(s, zero)
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
+
+// removeAt() removes the element at the index i of s
+func removeAt[T any](s []T, i int) []T {
+	copy(s[i:], s[i+1:])
+	return truncate(s, len(s)-1)
+}
+
+// truncate() shortens s to n elements, clearing the removed ones so that they can be garbage collected
+func truncate[T any](s []T, n int) []T {
+	var zero T
+	for i := n; i < len(s); i++ {
+		s[i] = zero
+	}
+	return s[:n]
+}
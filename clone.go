@@ -0,0 +1,103 @@
+package avlgo
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"reflect"
+)
+
+// Clone() returns a copy of the tree, with the same shape, in O(n)
+// Values are copied by assignment (see CloneFunc() for a deep copy)
+// Only the entries are copied : TTLs, capacity and observers are not
+func (t *Tree[K, V]) Clone() *Tree[K, V] {
+	return t.CloneFunc(func(value V) V { return value })
+}
+
+// Clone() returns a copy of the tree, with the same shape, in O(n), like Tree.Clone()
+func (t *UnsyncTree[K, V]) Clone() *UnsyncTree[K, V] {
+	return t.CloneFunc(func(value V) V { return value })
+}
+
+// CloneFunc() acts like Clone() but copies each value with copyValue
+func (t *Tree[K, V]) CloneFunc(copyValue func(value V) V) *Tree[K, V] {
+	t.rlockLive()
+	defer t.runlock()
+	return &Tree[K, V]{core: mapCore(&t.core, func(key K, value V) V {
+		return copyValue(value)
+	})}
+}
+
+// CloneFunc() acts like Clone() but copies each value with copyValue
+func (t *UnsyncTree[K, V]) CloneFunc(copyValue func(value V) V) *UnsyncTree[K, V] {
+	t.removeExpired()
+	return &UnsyncTree[K, V]{core: mapCore(&t.core, func(key K, value V) V {
+		return copyValue(value)
+	})}
+}
+
+// mapCore() returns a tree core with the keys and the shape of t, and the values fn(key, value)
+// (the tree must be read-locked)
+func mapCore[K Ordered, V, W any](t *core[K, V], fn func(key K, value V) W) core[K, W] {
+	mapped := core[K, W]{count: t.count}
+	if t.RootNode != nil {
+		mapped.RootNode = mapNode(t.RootNode, nil, fn)
+	}
+	return mapped
+}
+
+// Equal() returns true if the trees hold the same keys with equal values (compared with eq),
+// whatever their shapes
+func Equal[K Ordered, V any](a, b AnyTree[K, V], eq func(x, y V) bool) bool {
+	aKeys, aValues := entries(a)
+	bKeys, bValues := entries(b)
+	if len(aKeys) != len(bKeys) {
+		return false
+	}
+	for i := range aKeys {
+		if aKeys[i] != bKeys[i] || !eq(aValues[i], bValues[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Hash() returns a hash of the entries of the tree, in key order, so it doesn't depend on the shape of the tree
+// Each value is hashed with hashValue (if nil, only the keys are hashed)
+// The hash is stable between runs (FNV-1a), but it is not cryptographic
+func Hash[K Ordered, V any](t AnyTree[K, V], hashValue func(value V) uint64) uint64 {
+	h := fnv.New64a()
+	buffer := make([]byte, 8)
+	ascend(t, Unbounded[K](), Unbounded[K](), func(key K, value V) bool {
+		h.Write(keyBytes(key))
+		if hashValue != nil {
+			binary.BigEndian.PutUint64(buffer, hashValue(value))
+			h.Write(buffer)
+		}
+		return true
+	})
+	return h.Sum64()
+}
+
+// keyBytes() returns a binary representation of the key, prefixed by its kind
+// Equal keys have the same representation (for floats, -0 and +0 too)
+func keyBytes[K Ordered](key K) []byte {
+	v := reflect.ValueOf(key)
+	b := []byte{byte(v.Kind())}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return binary.BigEndian.AppendUint64(b, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return binary.BigEndian.AppendUint64(b, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if f == 0 {
+			f = 0 //-0 == +0
+		}
+		return binary.BigEndian.AppendUint64(b, math.Float64bits(f))
+	default:
+		//strings are prefixed by their length so "ab"+"c" and "a"+"bc" differ
+		b = binary.BigEndian.AppendUint64(b, uint64(v.Len()))
+		return append(b, v.String()...)
+	}
+}
@@ -0,0 +1,113 @@
+package avlgo
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantTree[int, []int](v)
+		for i := 0; i < 20; i++ {
+			tree.PutOne(i, []int{i})
+		}
+
+		clone := cloneOf(tree, nil)
+		if _, ok := rootOf(clone).isValid(); !ok {
+			t.Fatalf("the clone is not a valid AVL tree")
+		}
+		if !reflect.DeepEqual(clone.PrintKeys(2), tree.PrintKeys(2)) {
+			t.Errorf("the clone doesn't have the same shape")
+		}
+		clone.PutOne(100, nil)
+		clone.Delete(0)
+		if tree.Size() != 20 || clone.Size() != 20 {
+			t.Errorf("sizes are %d and %d, want 20 and 20", tree.Size(), clone.Size())
+		}
+		if _, ok := tree.Get(100); ok {
+			t.Errorf("changing the clone shouldn't change the tree")
+		}
+
+		//Clone() shares the values, CloneFunc() can copy them
+		shallow := cloneOf(tree, nil)
+		deep := cloneOf(tree, func(value []int) []int {
+			return append([]int(nil), value...)
+		})
+		value, _ := tree.Get(5)
+		value[0] = 50
+		if value, _ := shallow.Get(5); value[0] != 50 {
+			t.Errorf("Clone() should share the values")
+		}
+		if value, _ := deep.Get(5); value[0] != 5 {
+			t.Errorf("CloneFunc() should copy the values")
+		}
+	})
+}
+
+// cloneOf() calls the CloneFunc() method of the variant of the tree, or its Clone() method if copyValue is nil
+func cloneOf[K Ordered, V any](tree testTree[K, V], copyValue func(value V) V) testTree[K, V] {
+	switch tree := tree.(type) {
+	case *UnsyncTree[K, V]:
+		if copyValue == nil {
+			return tree.Clone()
+		}
+		return tree.CloneFunc(copyValue)
+	case *Tree[K, V]:
+		if copyValue == nil {
+			return tree.Clone()
+		}
+		return tree.CloneFunc(copyValue)
+	}
+	panic("unknown tree variant")
+}
+
+func TestEqualAndHash(t *testing.T) {
+	eq := func(x, y string) bool { return x == y }
+	hashValue := func(value string) uint64 {
+		return uint64(len(value))
+	}
+
+	a, b := NewTree[int, string](), NewTree[int, string]()
+	for i := 0; i < 50; i++ {
+		a.PutOne(i, "v")
+		b.PutOne(49-i, "v")
+	}
+	if reflect.DeepEqual(a.PrintKeys(1), b.PrintKeys(1)) {
+		t.Fatalf("the trees should have different shapes")
+	}
+	if !Equal[int, string](a, b, eq) {
+		t.Errorf("Equal should ignore the shapes")
+	}
+	if Hash[int, string](a, hashValue) != Hash[int, string](b, hashValue) {
+		t.Errorf("Hash should ignore the shapes")
+	}
+
+	b.PutOne(10, "vv")
+	if Equal[int, string](a, b, eq) || Hash[int, string](a, hashValue) == Hash[int, string](b, hashValue) {
+		t.Errorf("a changed value should change Equal and Hash")
+	}
+	if Hash[int, string](a, nil) != Hash[int, string](b, nil) {
+		t.Errorf("Hash without hashValue should only hash the keys")
+	}
+	b.Delete(10)
+	if Equal[int, string](a, b, eq) || Hash[int, string](a, nil) == Hash[int, string](b, nil) {
+		t.Errorf("a deleted key should change Equal and Hash")
+	}
+
+	strings1, strings2 := NewTree[string, int](), NewTree[string, int]()
+	strings1.PutOne("ab", 0)
+	strings1.PutOne("c", 0)
+	strings2.PutOne("a", 0)
+	strings2.PutOne("bc", 0)
+	if Hash[string, int](strings1, nil) == Hash[string, int](strings2, nil) {
+		t.Errorf("Hash shouldn't concatenate the keys")
+	}
+
+	zeros1, zeros2 := NewTree[float64, int](), NewTree[float64, int]()
+	zeros1.PutOne(0, 0)
+	zeros2.PutOne(math.Copysign(0, -1), 0)
+	if Hash[float64, int](zeros1, nil) != Hash[float64, int](zeros2, nil) {
+		t.Errorf("Hash should be the same for -0 and +0")
+	}
+}
@@ -0,0 +1,62 @@
+// Command avlgo-server serves a tree of JSON values over HTTP as an ordered key/value store (see the server package)
+//
+// Usage :
+//
+//	avlgo-server [-addr :8080] [-snapshot tree.gob] [-interval 30s]
+//
+// With -snapshot, the tree is read from the file at startup, written every interval if it changed,
+// on POST /snapshot and when the server is stopped by SIGINT or SIGTERM
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/darthyoh/avlgo/v2/server"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	snapshot := flag.String("snapshot", "", "snapshot file of the tree (no persistence if empty)")
+	interval := flag.Duration("interval", 30*time.Second, "interval between the snapshots (0 to only write them on POST /snapshot and at exit)")
+	flag.Parse()
+
+	s, err := server.Open(server.Options{SnapshotFile: *snapshot, SnapshotInterval: *interval})
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("%d keys loaded", s.Tree().Size())
+	stopSnapshots := s.Start(func(err error) { log.Print(err) })
+
+	httpServer := &http.Server{Addr: *addr, Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(ctx); err != nil {
+			log.Print(err)
+		}
+	}()
+
+	log.Printf("listening on %s", *addr)
+	if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+
+	//the requests are done : write the last changes
+	stopSnapshots()
+	if *snapshot != "" && *interval <= 0 {
+		if err := s.Snapshot(); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
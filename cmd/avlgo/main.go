@@ -0,0 +1,123 @@
+// Command avlgo inspects and edits the tree files written by Tree.Encode()
+//
+// Usage :
+//
+//	avlgo <command> [-key type] [-value type] [flags] file [arguments]
+//
+// Commands :
+//
+//	stats     prints the size, the height and the depth histogram of the tree
+//	validate  checks that the file holds a valid AVL tree
+//	get       prints the values of the keys
+//	range     prints the entries between -from and -to
+//	put       puts a key/value (the file is created if it doesn't exist)
+//	delete    deletes the keys
+//	render    draws the tree
+//	dump      writes the entries in JSON or CSV
+//	load      writes the file from entries in JSON or CSV
+//	convert   converts a file between the gob, json and csv formats
+//
+// The key type is int, int64, uint64, float64 or string, the value type is string, int, int64, float64, bool
+// or json (any JSON value). Both default to string : they must be the types the tree was encoded with
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "avlgo: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// command is a subcommand of the tool
+type command struct {
+	usage string
+	run   func(t tool, flags *flag.FlagSet, o *options) error
+}
+
+// options are the flags of the subcommands (each subcommand uses some of them)
+type options struct {
+	keyType, valueType string
+	from, to           string
+	fromExclusive      bool
+	toExclusive        bool
+	limit              int
+	format             string
+	fromFormat         string
+	toFormat           string
+	input, output      string
+}
+
+var commands = map[string]command{
+	"stats":    {"stats file", func(t tool, f *flag.FlagSet, o *options) error { return t.stats(f.Arg(0)) }},
+	"validate": {"validate file", func(t tool, f *flag.FlagSet, o *options) error { return t.validate(f.Arg(0)) }},
+	"get":      {"get file key...", func(t tool, f *flag.FlagSet, o *options) error { return t.get(f.Arg(0), f.Args()[1:]) }},
+	"range":    {"range [-from key] [-to key] [-from-exclusive] [-to-exclusive] [-limit n] file", func(t tool, f *flag.FlagSet, o *options) error { return t.rangeEntries(f.Arg(0), o) }},
+	"put":      {"put file key value", func(t tool, f *flag.FlagSet, o *options) error { return t.put(f.Arg(0), f.Arg(1), f.Arg(2)) }},
+	"delete":   {"delete file key...", func(t tool, f *flag.FlagSet, o *options) error { return t.delete(f.Arg(0), f.Args()[1:]) }},
+	"render":   {"render file", func(t tool, f *flag.FlagSet, o *options) error { return t.render(f.Arg(0)) }},
+	"dump":     {"dump [-format json|csv] [-o output] file", func(t tool, f *flag.FlagSet, o *options) error { return t.dump(f.Arg(0), o) }},
+	"load":     {"load [-format json|csv] [-i input] file", func(t tool, f *flag.FlagSet, o *options) error { return t.load(f.Arg(0), o) }},
+	"convert":  {"convert [-from gob|json|csv] [-to gob|json|csv] input output", func(t tool, f *flag.FlagSet, o *options) error { return t.convert(f.Arg(0), f.Arg(1), o) }},
+}
+
+// minArgs is the number of arguments of each subcommand
+var minArgs = map[string]int{
+	"stats": 1, "validate": 1, "get": 2, "range": 1, "put": 3, "delete": 2, "render": 1, "dump": 1, "load": 1, "convert": 2,
+}
+
+// run() runs the subcommand of args, reading the standard input from stdin and writing the results in stdout
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage())
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage())
+	}
+
+	o := &options{}
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&o.keyType, "key", "string", "type of the keys : int, int64, uint64, float64 or string")
+	flags.StringVar(&o.valueType, "value", "string", "type of the values : string, int, int64, float64, bool or json")
+	flags.StringVar(&o.from, "from", "", "lower bound of the range (unbounded if empty), or input format for convert")
+	flags.StringVar(&o.to, "to", "", "upper bound of the range (unbounded if empty), or output format for convert")
+	flags.BoolVar(&o.fromExclusive, "from-exclusive", false, "exclude the lower bound")
+	flags.BoolVar(&o.toExclusive, "to-exclusive", false, "exclude the upper bound")
+	flags.IntVar(&o.limit, "limit", 0, "maximum number of entries (0 for all)")
+	flags.StringVar(&o.format, "format", "json", "format of the entries : json or csv")
+	flags.StringVar(&o.input, "i", "", "input file (standard input if empty)")
+	flags.StringVar(&o.output, "o", "", "output file (standard output if empty)")
+	if err := flags.Parse(args[1:]); err != nil {
+		return fmt.Errorf("%s\nusage : avlgo %s", err, cmd.usage)
+	}
+	if flags.NArg() < minArgs[args[0]] {
+		return fmt.Errorf("missing arguments\nusage : avlgo %s", cmd.usage)
+	}
+	if args[0] == "convert" { //-from and -to are formats for convert
+		o.fromFormat, o.toFormat = o.from, o.to
+	}
+
+	t, err := newTool(o.keyType, o.valueType, stdin, stdout)
+	if err != nil {
+		return err
+	}
+	return cmd.run(t, flags, o)
+}
+
+// usage() returns the list of the subcommands
+func usage() string {
+	s := "usage : avlgo <command> [-key type] [-value type] [flags] file [arguments]\ncommands :"
+	for _, name := range []string{"stats", "validate", "get", "range", "put", "delete", "render", "dump", "load", "convert"} {
+		s += "\n  avlgo " + commands[name].usage
+	}
+	return s
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runTool() runs the tool and returns its output
+func runTool(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	var stdout bytes.Buffer
+	err := run(args, strings.NewReader(stdin), &stdout)
+	return stdout.String(), err
+}
+
+func TestPutGetDelete(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tree.gob")
+	for _, k := range []string{"3", "1", "2", "5", "4"} {
+		if _, err := runTool(t, "", "put", "-key", "int", "-value", "json", file, k, `{"id":`+k+`}`); err != nil {
+			t.Fatalf("put %s shouldn't return an error. %s is returned", k, err)
+		}
+	}
+
+	if out, err := runTool(t, "", "get", "-key", "int", "-value", "json", file, "2", "5"); err != nil || out != "{\"id\":2}\n{\"id\":5}\n" {
+		t.Errorf("get returns %q, %v", out, err)
+	}
+	if out, err := runTool(t, "", "get", "-key", "int", "-value", "json", file, "9"); err == nil || out != "9 not found\n" {
+		t.Errorf("get of a missing key returns %q, %v", out, err)
+	}
+	if _, err := runTool(t, "", "put", "-key", "int", "-value", "json", file, "6", "{"); err == nil {
+		t.Errorf("put of an invalid JSON value should return an error")
+	}
+
+	if out, err := runTool(t, "", "delete", "-key", "int", "-value", "json", file, "1", "9"); err != nil || out != "1 deleted\n" {
+		t.Errorf("delete returns %q, %v", out, err)
+	}
+	if out, err := runTool(t, "", "validate", "-key", "int", "-value", "json", file); err != nil || out != "ok : 4 entries, height 3\n" {
+		t.Errorf("validate returns %q, %v", out, err)
+	}
+
+	//the types must be the ones of the file
+	if _, err := runTool(t, "", "get", file, "2"); err == nil {
+		t.Errorf("get with string keys should return an error")
+	}
+}
+
+func TestRangeStatsRender(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tree.gob")
+	for _, k := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		if _, err := runTool(t, "", "put", file, k, strings.ToUpper(k)); err != nil {
+			t.Fatalf("put %s shouldn't return an error. %s is returned", k, err)
+		}
+	}
+
+	if out, _ := runTool(t, "", "range", "-from", "b", "-from-exclusive", "-to", "f", "-limit", "3", file); out != "c\tC\nd\tD\ne\tE\n" {
+		t.Errorf("range returns %q", out)
+	}
+	if out, _ := runTool(t, "", "range", "-to", "b", file); out != "a\tA\nb\tB\n" {
+		t.Errorf("range returns %q", out)
+	}
+	if out, _ := runTool(t, "", "stats", file); !strings.HasPrefix(out, "size\t7\nheight\t3\nmin height\t3\ndepth 1\t1\ndepth 2\t2\ndepth 3\t4\n") {
+		t.Errorf("stats returns %q", out)
+	}
+	if out, _ := runTool(t, "", "render", file); out != "d\n+-- b\n|   +-- a\n|   `-- c\n`-- f\n    +-- e\n    `-- g\n" {
+		t.Errorf("render returns\n%s", out)
+	}
+}
+
+func TestDumpLoadConvert(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tree.gob")
+
+	csv := "key,value\n2.5,20\n-1,10\n"
+	if out, err := runTool(t, csv, "load", "-key", "float64", "-value", "int", "-format", "csv", file); err != nil || out != "2 entries loaded\n" {
+		t.Fatalf("load returns %q, %v", out, err)
+	}
+	if out, _ := runTool(t, "", "dump", "-key", "float64", "-value", "int", "-format", "csv", file); out != "key,value\n-1,10\n2.5,20\n" {
+		t.Errorf("dump returns %q", out)
+	}
+
+	jsonFile, csvFile := filepath.Join(dir, "tree.json"), filepath.Join(dir, "tree.csv")
+	if _, err := runTool(t, "", "convert", "-key", "float64", "-value", "int", file, jsonFile); err != nil {
+		t.Fatalf("convert shouldn't return an error. %s is returned", err)
+	}
+	if _, err := runTool(t, "", "convert", "-key", "float64", "-value", "int", jsonFile, csvFile); err != nil {
+		t.Fatalf("convert shouldn't return an error. %s is returned", err)
+	}
+	if b, _ := os.ReadFile(csvFile); string(b) != "key,value\n-1,10\n2.5,20\n" {
+		t.Errorf("converted file is %q", b)
+	}
+	if _, err := runTool(t, "", "convert", "-key", "float64", "-value", "int", "-from", "csv", "-to", "gob", csvFile, file); err != nil {
+		t.Fatalf("convert shouldn't return an error. %s is returned", err)
+	}
+	if out, _ := runTool(t, "", "get", "-key", "float64", "-value", "int", file, "2.5"); out != "20\n" {
+		t.Errorf("get returns %q", out)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	if _, err := runTool(t, ""); err == nil || !strings.Contains(err.Error(), "avlgo convert") {
+		t.Errorf("no command should return the usage. %v is returned", err)
+	}
+	if _, err := runTool(t, "", "list"); err == nil {
+		t.Errorf("an unknown command should return an error")
+	}
+	if _, err := runTool(t, "", "put", "file.gob", "key"); err == nil {
+		t.Errorf("a missing argument should return an error")
+	}
+	if _, err := runTool(t, "", "stats", "-key", "complex128", "file.gob"); err == nil {
+		t.Errorf("an unknown key type should return an error")
+	}
+}
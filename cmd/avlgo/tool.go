@@ -0,0 +1,451 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/darthyoh/avlgo/v2"
+)
+
+// tool runs the subcommands on the trees of one key type and one value type
+type tool interface {
+	stats(file string) error
+	validate(file string) error
+	get(file string, keys []string) error
+	rangeEntries(file string, o *options) error
+	put(file, key, value string) error
+	delete(file string, keys []string) error
+	render(file string) error
+	dump(file string, o *options) error
+	load(file string, o *options) error
+	convert(input, output string, o *options) error
+}
+
+// typedTool is the tool for the trees of K keys and V values
+type typedTool[K avlgo.Ordered, V any] struct {
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+// record is an entry in the JSON format
+type record[K avlgo.Ordered, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
+
+// newTool() returns the tool for the key and value types
+func newTool(keyType, valueType string, stdin io.Reader, stdout io.Writer) (tool, error) {
+	switch keyType {
+	case "int":
+		return newValueTool[int](valueType, stdin, stdout)
+	case "int64":
+		return newValueTool[int64](valueType, stdin, stdout)
+	case "uint64":
+		return newValueTool[uint64](valueType, stdin, stdout)
+	case "float64":
+		return newValueTool[float64](valueType, stdin, stdout)
+	case "string":
+		return newValueTool[string](valueType, stdin, stdout)
+	default:
+		return nil, fmt.Errorf("unknown key type %q (int, int64, uint64, float64 or string)", keyType)
+	}
+}
+
+// newValueTool() returns the tool for the K keys and the value type
+func newValueTool[K avlgo.Ordered](valueType string, stdin io.Reader, stdout io.Writer) (tool, error) {
+	switch valueType {
+	case "string":
+		return &typedTool[K, string]{stdin, stdout}, nil
+	case "int":
+		return &typedTool[K, int]{stdin, stdout}, nil
+	case "int64":
+		return &typedTool[K, int64]{stdin, stdout}, nil
+	case "float64":
+		return &typedTool[K, float64]{stdin, stdout}, nil
+	case "bool":
+		return &typedTool[K, bool]{stdin, stdout}, nil
+	case "json":
+		return &typedTool[K, json.RawMessage]{stdin, stdout}, nil
+	default:
+		return nil, fmt.Errorf("unknown value type %q (string, int, int64, float64, bool or json)", valueType)
+	}
+}
+
+// parse() parses a key or a value given on the command line or in a CSV file
+func parse[T any](s string) (value T, err error) {
+	switch v := any(&value).(type) {
+	case *string:
+		*v = s
+	case *int:
+		*v, err = strconv.Atoi(s)
+	case *int64:
+		*v, err = strconv.ParseInt(s, 10, 64)
+	case *uint64:
+		*v, err = strconv.ParseUint(s, 10, 64)
+	case *float64:
+		*v, err = strconv.ParseFloat(s, 64)
+	case *bool:
+		*v, err = strconv.ParseBool(s)
+	case *json.RawMessage:
+		if !json.Valid([]byte(s)) {
+			return value, fmt.Errorf("%q is not a valid JSON value", s)
+		}
+		*v = json.RawMessage(s)
+	}
+	if err != nil {
+		return value, fmt.Errorf("unable to parse %q : %s", s, err)
+	}
+	return value, nil
+}
+
+// format() formats a key or a value for the output or a CSV file
+func format[T any](value T) string {
+	switch v := any(value).(type) {
+	case json.RawMessage:
+		return string(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formatOf() returns the format of a file : the wanted one, otherwise the one of its extension (gob by default)
+func formatOf(file, wanted string) (string, error) {
+	if wanted == "" {
+		wanted = strings.TrimPrefix(filepath.Ext(file), ".")
+	}
+	switch wanted {
+	case "gob", "json", "csv":
+		return wanted, nil
+	case "":
+		return "gob", nil
+	default:
+		return "", fmt.Errorf("unknown format %q (gob, json or csv)", wanted)
+	}
+}
+
+// read() reads a tree file in gob format
+func (t *typedTool[K, V]) read(file string) (*avlgo.Tree[K, V], error) {
+	return avlgo.Decode[K, V](file)
+}
+
+// write() writes the tree in a gob file, through a temporary file so a failure doesn't lose the previous content
+func (t *typedTool[K, V]) write(tree *avlgo.Tree[K, V], file string) error {
+	temporary := file + ".tmp"
+	if err := tree.Encode(temporary); err != nil {
+		os.Remove(temporary)
+		return err
+	}
+	if err := os.Rename(temporary, file); err != nil {
+		return fmt.Errorf("unable to write %s : %s", file, err)
+	}
+	return nil
+}
+
+func (t *typedTool[K, V]) stats(file string) error {
+	tree, err := t.read(file)
+	if err != nil {
+		return err
+	}
+	stats := tree.Stats()
+	fmt.Fprintf(t.stdout, "size\t%d\nheight\t%d\nmin height\t%d\n", stats.Size, stats.Height, stats.MinHeight)
+	for i, nodes := range stats.DepthHistogram {
+		fmt.Fprintf(t.stdout, "depth %d\t%d\n", i+1, nodes)
+	}
+	return nil
+}
+
+func (t *typedTool[K, V]) validate(file string) error {
+	//Decode() rejects the files which don't hold a valid AVL tree
+	tree, err := t.read(file)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(t.stdout, "ok : %d entries, height %d\n", tree.Size(), tree.Depth())
+	return nil
+}
+
+func (t *typedTool[K, V]) get(file string, keys []string) error {
+	tree, err := t.read(file)
+	if err != nil {
+		return err
+	}
+	missing := 0
+	for _, s := range keys {
+		key, err := parse[K](s)
+		if err != nil {
+			return err
+		}
+		if value, ok := tree.Get(key); ok {
+			fmt.Fprintln(t.stdout, format(value))
+		} else {
+			fmt.Fprintf(t.stdout, "%s not found\n", s)
+			missing++
+		}
+	}
+	if missing > 0 {
+		return fmt.Errorf("%d keys not found", missing)
+	}
+	return nil
+}
+
+// bound() parses a bound of the range command (unbounded if empty)
+func (t *typedTool[K, V]) bound(s string, exclusive bool) (avlgo.Bound[K], error) {
+	if s == "" {
+		return avlgo.Unbounded[K](), nil
+	}
+	key, err := parse[K](s)
+	if err != nil {
+		return avlgo.Bound[K]{}, err
+	}
+	if exclusive {
+		return avlgo.Exclusive(key), nil
+	}
+	return avlgo.Inclusive(key), nil
+}
+
+func (t *typedTool[K, V]) rangeEntries(file string, o *options) error {
+	tree, err := t.read(file)
+	if err != nil {
+		return err
+	}
+	lo, err := t.bound(o.from, o.fromExclusive)
+	if err != nil {
+		return err
+	}
+	hi, err := t.bound(o.to, o.toExclusive)
+	if err != nil {
+		return err
+	}
+	count := 0
+	return tree.AscendRange(lo, hi, func(key K, value V) bool {
+		fmt.Fprintf(t.stdout, "%s\t%s\n", format(key), format(value))
+		count++
+		return o.limit <= 0 || count < o.limit
+	})
+}
+
+func (t *typedTool[K, V]) put(file, s, v string) error {
+	tree := avlgo.NewTree[K, V]()
+	if _, err := os.Stat(file); !errors.Is(err, os.ErrNotExist) {
+		if tree, err = t.read(file); err != nil {
+			return err
+		}
+	}
+	key, err := parse[K](s)
+	if err != nil {
+		return err
+	}
+	value, err := parse[V](v)
+	if err != nil {
+		return err
+	}
+	if !tree.PutOne(key, value) {
+		return fmt.Errorf("unable to put %s : NaN can't be a key", s)
+	}
+	return t.write(tree, file)
+}
+
+func (t *typedTool[K, V]) delete(file string, keys []string) error {
+	tree, err := t.read(file)
+	if err != nil {
+		return err
+	}
+	parsed := make([]K, 0, len(keys))
+	for _, s := range keys {
+		key, err := parse[K](s)
+		if err != nil {
+			return err
+		}
+		parsed = append(parsed, key)
+	}
+	fmt.Fprintf(t.stdout, "%d deleted\n", tree.Delete(parsed...))
+	return t.write(tree, file)
+}
+
+func (t *typedTool[K, V]) render(file string) error {
+	tree, err := t.read(file)
+	if err != nil {
+		return err
+	}
+	if tree.RootNode == nil {
+		fmt.Fprintln(t.stdout, "(empty)")
+		return nil
+	}
+	draw(t.stdout, tree.RootNode, "", "")
+	return nil
+}
+
+// draw() writes the subtree of the node, one node per line, the Previous child before the Next child
+// (a missing child is drawn as a dot)
+func draw[K avlgo.Ordered, V any](w io.Writer, n *avlgo.Node[K, V], prefix, childPrefix string) {
+	fmt.Fprintf(w, "%s%s\n", prefix, format(n.Key))
+	if n.Previous == nil && n.Next == nil {
+		return
+	}
+	if n.Previous == nil {
+		fmt.Fprintf(w, "%s+-- .\n", childPrefix)
+	} else {
+		draw(w, n.Previous, childPrefix+"+-- ", childPrefix+"|   ")
+	}
+	if n.Next == nil {
+		fmt.Fprintf(w, "%s`-- .\n", childPrefix)
+	} else {
+		draw(w, n.Next, childPrefix+"`-- ", childPrefix+"    ")
+	}
+}
+
+func (t *typedTool[K, V]) dump(file string, o *options) error {
+	tree, err := t.read(file)
+	if err != nil {
+		return err
+	}
+	entriesFormat, err := formatOf("", o.format)
+	if err != nil {
+		return err
+	}
+	output := t.stdout
+	if o.output != "" {
+		f, err := os.Create(o.output)
+		if err != nil {
+			return fmt.Errorf("unable to create the output file : %s", err)
+		}
+		defer f.Close()
+		output = f
+	}
+	return t.writeEntries(tree, output, entriesFormat)
+}
+
+func (t *typedTool[K, V]) load(file string, o *options) error {
+	entriesFormat, err := formatOf("", o.format)
+	if err != nil {
+		return err
+	}
+	input := t.stdin
+	if o.input != "" {
+		f, err := os.Open(o.input)
+		if err != nil {
+			return fmt.Errorf("unable to open the input file : %s", err)
+		}
+		defer f.Close()
+		input = f
+	}
+	tree, err := t.readEntries(input, entriesFormat)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(t.stdout, "%d entries loaded\n", tree.Size())
+	return t.write(tree, file)
+}
+
+func (t *typedTool[K, V]) convert(input, output string, o *options) error {
+	inputFormat, err := formatOf(input, o.fromFormat)
+	if err != nil {
+		return err
+	}
+	outputFormat, err := formatOf(output, o.toFormat)
+	if err != nil {
+		return err
+	}
+
+	var tree *avlgo.Tree[K, V]
+	if inputFormat == "gob" {
+		tree, err = t.read(input)
+	} else {
+		var f *os.File
+		if f, err = os.Open(input); err != nil {
+			return fmt.Errorf("unable to open the input file : %s", err)
+		}
+		defer f.Close()
+		tree, err = t.readEntries(f, inputFormat)
+	}
+	if err != nil {
+		return err
+	}
+
+	if outputFormat == "gob" {
+		return t.write(tree, output)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("unable to create the output file : %s", err)
+	}
+	defer f.Close()
+	return t.writeEntries(tree, f, outputFormat)
+}
+
+// writeEntries() writes the entries of the tree in the json or csv format
+func (t *typedTool[K, V]) writeEntries(tree *avlgo.Tree[K, V], w io.Writer, entriesFormat string) error {
+	if entriesFormat == "csv" {
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"key", "value"})
+		tree.Ascend(func(key K, value V) bool {
+			writer.Write([]string{format(key), format(value)})
+			return true
+		})
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return fmt.Errorf("unable to write CSV : %s", err)
+		}
+		return nil
+	}
+
+	records := make([]record[K, V], 0, tree.Size())
+	tree.Ascend(func(key K, value V) bool {
+		records = append(records, record[K, V]{Key: key, Value: value})
+		return true
+	})
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(records); err != nil {
+		return fmt.Errorf("unable to write JSON : %s", err)
+	}
+	return nil
+}
+
+// readEntries() reads entries in the json or csv format and returns them in a new tree
+func (t *typedTool[K, V]) readEntries(r io.Reader, entriesFormat string) (*avlgo.Tree[K, V], error) {
+	tree := avlgo.NewTree[K, V]()
+	if entriesFormat == "csv" {
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = 2
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CSV : %s", err)
+		}
+		if len(rows) > 0 && rows[0][0] == "key" && rows[0][1] == "value" { //header
+			rows = rows[1:]
+		}
+		for _, row := range rows {
+			key, err := parse[K](row[0])
+			if err != nil {
+				return nil, err
+			}
+			value, err := parse[V](row[1])
+			if err != nil {
+				return nil, err
+			}
+			if !tree.PutOne(key, value) {
+				return nil, fmt.Errorf("unable to put %s : NaN can't be a key", row[0])
+			}
+		}
+		return tree, nil
+	}
+
+	records := make([]record[K, V], 0)
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, fmt.Errorf("unable to read JSON : %s", err)
+	}
+	for _, r := range records {
+		tree.PutOne(r.Key, r.Value)
+	}
+	return tree, nil
+}
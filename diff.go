@@ -0,0 +1,119 @@
+package avlgo
+
+import "fmt"
+
+// ChangeType is the kind of difference between two trees
+type ChangeType int
+
+const (
+	ChangeAdded   ChangeType = iota // the key is only in the second tree
+	ChangeRemoved                   // the key is only in the first tree
+	ChangeChanged                   // the key is in both trees with different values
+)
+
+// Change is a difference between two trees for one key
+type Change[K Ordered, V any] struct {
+	Type ChangeType
+	Key  K
+	Old  V // the value in the first tree (zero value for ChangeAdded)
+	New  V // the value in the second tree (zero value for ChangeRemoved)
+}
+
+// Conflict is a key changed differently in ours and theirs since base
+// The In* fields tell whether the key is present in each tree
+type Conflict[K Ordered, V any] struct {
+	Key                      K
+	Base, Ours, Theirs       V
+	InBase, InOurs, InTheirs bool
+}
+
+// Diff() returns the ordered changes turning the tree a into the tree b
+// Values are compared with eq. Both trees are walked once, in key order, so it runs in O(n+m)
+func Diff[K Ordered, V any](a, b AnyTree[K, V], eq func(x, y V) bool) (changes []Change[K, V]) {
+	aKeys, aValues := entries(a)
+	bKeys, bValues := entries(b)
+
+	i, j := 0, 0
+	for i < len(aKeys) || j < len(bKeys) {
+		switch {
+		case j == len(bKeys) || (i < len(aKeys) && aKeys[i] < bKeys[j]):
+			changes = append(changes, Change[K, V]{Type: ChangeRemoved, Key: aKeys[i], Old: aValues[i]})
+			i++
+		case i == len(aKeys) || aKeys[i] > bKeys[j]:
+			changes = append(changes, Change[K, V]{Type: ChangeAdded, Key: bKeys[j], New: bValues[j]})
+			j++
+		default:
+			if !eq(aValues[i], bValues[j]) {
+				changes = append(changes, Change[K, V]{Type: ChangeChanged, Key: aKeys[i], Old: aValues[i], New: bValues[j]})
+			}
+			i++
+			j++
+		}
+	}
+	return changes
+}
+
+// Merge3() returns a new tree merging the changes made in ours and in theirs since base
+// A key changed in only one of them takes this change, a key changed the same way in both takes it too.
+// A key changed differently is a conflict : resolve() returns its merged value (keep is false to remove the key).
+// If resolve is nil, a conflict returns an error
+// Values are compared with eq, and the trees are walked once, in key order
+func Merge3[K Ordered, V any](base, ours, theirs AnyTree[K, V], eq func(x, y V) bool, resolve func(conflict Conflict[K, V]) (value V, keep bool)) (*Tree[K, V], error) {
+	var cursors [3]struct {
+		keys   []K
+		values []V
+		i      int
+	}
+	for c, tree := range []AnyTree[K, V]{base, ours, theirs} {
+		cursors[c].keys, cursors[c].values = entries(tree)
+	}
+
+	keys, values := make([]K, 0), make([]V, 0)
+	for {
+		//the next key is the smallest key under the cursors
+		var key K
+		found := false
+		for c := range cursors {
+			if cursors[c].i < len(cursors[c].keys) && (!found || cursors[c].keys[cursors[c].i] < key) {
+				key, found = cursors[c].keys[cursors[c].i], true
+			}
+		}
+		if !found {
+			break
+		}
+
+		var conflict Conflict[K, V]
+		conflict.Key = key
+		present := [3]*bool{&conflict.InBase, &conflict.InOurs, &conflict.InTheirs}
+		value := [3]*V{&conflict.Base, &conflict.Ours, &conflict.Theirs}
+		for c := range cursors {
+			if cursors[c].i < len(cursors[c].keys) && cursors[c].keys[cursors[c].i] == key {
+				*present[c], *value[c] = true, cursors[c].values[cursors[c].i]
+				cursors[c].i++
+			}
+		}
+
+		same := func(inA bool, a V, inB bool, b V) bool {
+			return inA == inB && (!inA || eq(a, b))
+		}
+		var merged V
+		var keep bool
+		switch {
+		case same(conflict.InOurs, conflict.Ours, conflict.InTheirs, conflict.Theirs),
+			same(conflict.InBase, conflict.Base, conflict.InTheirs, conflict.Theirs):
+			merged, keep = conflict.Ours, conflict.InOurs
+		case same(conflict.InBase, conflict.Base, conflict.InOurs, conflict.Ours):
+			merged, keep = conflict.Theirs, conflict.InTheirs
+		case resolve == nil:
+			return nil, fmt.Errorf("unable to merge : conflict on key %v", key)
+		default:
+			merged, keep = resolve(conflict)
+		}
+		if keep {
+			keys = append(keys, key)
+			values = append(values, merged)
+		}
+	}
+
+	return newTreeFromSorted(keys, values), nil
+}
@@ -0,0 +1,70 @@
+package avlgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newTreeOf(entries map[string]int) *Tree[string, int] {
+	tree := NewTree[string, int]()
+	for k, v := range entries {
+		tree.PutOne(k, v)
+	}
+	return tree
+}
+
+func TestDiff(t *testing.T) {
+	eq := func(x, y int) bool { return x == y }
+	a := newTreeOf(map[string]int{"a": 1, "b": 2, "c": 3, "e": 5})
+	b := newTreeOf(map[string]int{"b": 2, "c": 30, "d": 4, "f": 6})
+
+	changes := Diff[string, int](a, b, eq)
+	wanted := []Change[string, int]{
+		{Type: ChangeRemoved, Key: "a", Old: 1},
+		{Type: ChangeChanged, Key: "c", Old: 3, New: 30},
+		{Type: ChangeAdded, Key: "d", New: 4},
+		{Type: ChangeRemoved, Key: "e", Old: 5},
+		{Type: ChangeAdded, Key: "f", New: 6},
+	}
+	if !reflect.DeepEqual(changes, wanted) {
+		t.Errorf("changes are %v, want %v", changes, wanted)
+	}
+	if changes := Diff[string, int](a, a, eq); len(changes) != 0 {
+		t.Errorf("changes are %v, want none", changes)
+	}
+	if changes := Diff[string, int](NewTree[string, int](), b, eq); len(changes) != 4 {
+		t.Errorf("Diff finds %d changes, want 4", len(changes))
+	}
+}
+
+func TestMerge3(t *testing.T) {
+	eq := func(x, y int) bool { return x == y }
+	base := newTreeOf(map[string]int{"same": 1, "ours": 2, "theirs": 3, "both": 4, "conflict": 5, "deleted": 6, "deleted-changed": 7})
+	ours := newTreeOf(map[string]int{"same": 1, "ours": 20, "theirs": 3, "both": 40, "conflict": 50, "deleted-changed": 70, "added": 8})
+	theirs := newTreeOf(map[string]int{"same": 1, "ours": 2, "theirs": 30, "both": 40, "conflict": 500, "added": 8})
+
+	if _, err := Merge3[string, int](base, ours, theirs, eq, nil); err == nil {
+		t.Errorf("Merge3 should return an error without resolver")
+	}
+
+	conflicts := make([]string, 0)
+	merged, err := Merge3[string, int](base, ours, theirs, eq, func(conflict Conflict[string, int]) (int, bool) {
+		conflicts = append(conflicts, conflict.Key)
+		if !conflict.InTheirs {
+			return 0, false
+		}
+		return conflict.Ours + conflict.Theirs, true
+	})
+	if err != nil {
+		t.Fatalf("Merge3 shouldn't return an error. %s is returned", err)
+	}
+	if !reflect.DeepEqual(conflicts, []string{"conflict", "deleted-changed"}) {
+		t.Errorf("conflicts are %v, want %v", conflicts, []string{"conflict", "deleted-changed"})
+	}
+	keys, values := merged.PrintKeys(0), merged.PrintValues(0)
+	wantedKeys := []string{"added", "both", "conflict", "ours", "same", "theirs"}
+	wantedValues := []int{8, 40, 550, 20, 1, 30}
+	if !reflect.DeepEqual(keys, wantedKeys) || !reflect.DeepEqual(values, wantedValues) {
+		t.Errorf("merged tree is %v %v, want %v %v", keys, values, wantedKeys, wantedValues)
+	}
+}
@@ -0,0 +1,127 @@
+package avlgo
+
+// DeleteRange() removes the keys between the bounds lo and hi and returns the number of removed keys
+// The tree is split around the range and joined back : the structural work is O(log n), whatever the number of removed keys
+func (t *Tree[K, V]) DeleteRange(lo, hi Bound[K]) (int, error) {
+	removed, err := t.Extract(lo, hi)
+	if err != nil {
+		return 0, err
+	}
+	return removed.count, nil
+}
+
+// DeleteRange() removes the keys between the bounds lo and hi and returns the number of removed keys, like Tree.DeleteRange()
+func (t *UnsyncTree[K, V]) DeleteRange(lo, hi Bound[K]) (int, error) {
+	removed, err := t.Extract(lo, hi)
+	if err != nil {
+		return 0, err
+	}
+	return removed.count, nil
+}
+
+// Extract() removes the keys between the bounds lo and hi and returns them in a new Tree,
+// like DeleteRange() (the removed entries aren't copied)
+func (t *Tree[K, V]) Extract(lo, hi Bound[K]) (*Tree[K, V], error) {
+	if err := validRange(lo, hi); err != nil {
+		return nil, err
+	}
+	t.lock()
+	removed := &Tree[K, V]{core: t.extract(lo, hi)}
+	changes := t.settle(nil)
+	t.unlock()
+
+	changes.notify()
+	return removed, nil
+}
+
+// Extract() removes the keys between the bounds lo and hi and returns them in a new UnsyncTree, like Tree.Extract()
+func (t *UnsyncTree[K, V]) Extract(lo, hi Bound[K]) (*UnsyncTree[K, V], error) {
+	if err := validRange(lo, hi); err != nil {
+		return nil, err
+	}
+	removed := &UnsyncTree[K, V]{core: t.extract(lo, hi)}
+	t.settle(nil).notify()
+	return removed, nil
+}
+
+// DeleteFunc() removes the entries for which pred returns true and returns the number of removed entries
+// The kept nodes are linked back in a balanced tree in one pass : it runs in O(n) without any rotation
+// pred is called with the tree locked, so it must not use the tree
+func (t *Tree[K, V]) DeleteFunc(pred func(key K, value V) bool) int {
+	t.lock()
+	removed := t.deleteFunc(pred)
+	changes := t.settle(nil)
+	t.unlock()
+
+	changes.notify()
+	return removed
+}
+
+// DeleteFunc() removes the entries for which pred returns true and returns the number of removed entries, like Tree.DeleteFunc()
+// pred must not use the tree
+func (t *UnsyncTree[K, V]) DeleteFunc(pred func(key K, value V) bool) int {
+	removed := t.deleteFunc(pred)
+	t.settle(nil).notify()
+	return removed
+}
+
+// Retain() keeps only the entries for which pred returns true and returns the number of removed entries, like DeleteFunc()
+func (t *Tree[K, V]) Retain(pred func(key K, value V) bool) int {
+	return t.DeleteFunc(negate(pred))
+}
+
+// Retain() keeps only the entries for which pred returns true and returns the number of removed entries, like DeleteFunc()
+func (t *UnsyncTree[K, V]) Retain(pred func(key K, value V) bool) int {
+	return t.DeleteFunc(negate(pred))
+}
+
+// negate() returns the opposite of pred
+func negate[K Ordered, V any](pred func(key K, value V) bool) func(key K, value V) bool {
+	return func(key K, value V) bool {
+		return !pred(key, value)
+	}
+}
+
+// deleteFunc() removes the entries for which pred returns true (the tree must be locked)
+func (t *core[K, V]) deleteFunc(pred func(key K, value V) bool) int {
+	kept := make([]*Node[K, V], 0)
+	removed := 0
+	if t.RootNode != nil {
+		t.RootNode.ascend(Unbounded[K](), Unbounded[K](), func(node *Node[K, V]) bool {
+			if pred(node.Key, node.Value) {
+				t.forgetNode(node)
+				removed++
+			} else {
+				kept = append(kept, node)
+			}
+			return true
+		})
+	}
+	if removed > 0 {
+		t.RootNode = relink(kept, nil)
+	}
+	return removed
+}
+
+// extract() removes the keys between the bounds lo and hi and returns them in a new tree core (the tree must be locked)
+func (t *core[K, V]) extract(lo, hi Bound[K]) (removed core[K, V]) {
+	if t.RootNode == nil {
+		return removed
+	}
+	left, rest := t.RootNode.split(func(key K) bool {
+		return !lo.admitsAbove(key)
+	}, &t.probe)
+	removed.RootNode, rest = rest.split(func(key K) bool {
+		return hi.admitsBelow(key)
+	}, &t.probe)
+	t.RootNode = join2(left, rest, &t.probe)
+
+	if removed.RootNode != nil {
+		removed.RootNode.ascend(Unbounded[K](), Unbounded[K](), func(node *Node[K, V]) bool {
+			t.forgetNode(node)
+			removed.count++
+			return true
+		})
+	}
+	return removed
+}
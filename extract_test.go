@@ -0,0 +1,149 @@
+package avlgo
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestDeleteRangeRandom(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		r := rand.New(rand.NewSource(1))
+		bound := func() Bound[int] {
+			switch r.Intn(3) {
+			case 0:
+				return Inclusive(r.Intn(1000))
+			case 1:
+				return Exclusive(r.Intn(1000))
+			default:
+				return Unbounded[int]()
+			}
+		}
+
+		for round := 0; round < 200; round++ {
+			tree, m := newVariantTree[int, int](v), newModel()
+			for i := r.Intn(500); i > 0; i-- {
+				k := r.Intn(1000)
+				tree.PutOne(k, k)
+				m.put(k, k)
+			}
+
+			lo, hi := bound(), bound()
+			if validRange(lo, hi) != nil {
+				lo, hi = hi, lo
+			}
+			wantDeleted := 0
+			for _, k := range append([]int(nil), m.keys...) {
+				if lo.admitsAbove(k) && hi.admitsBelow(k) {
+					wantDeleted += m.delete(k)
+				}
+			}
+
+			deleted, err := tree.DeleteRange(lo, hi)
+			if err != nil {
+				t.Fatalf("DeleteRange(%v, %v) shouldn't return an error. %s is returned", lo, hi, err)
+			}
+			if deleted != wantDeleted {
+				t.Fatalf("DeleteRange(%v, %v) deleted %d keys, want %d", lo, hi, deleted, wantDeleted)
+			}
+			checkTree(t, tree, m)
+		}
+	})
+}
+
+func TestExtract(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantTree[int, int](v)
+		for i := 0; i < 100; i++ {
+			tree.PutOne(i, i*10)
+		}
+
+		extracted, err := extractOf(tree, Inclusive(20), Exclusive(40))
+		if err != nil {
+			t.Fatalf("Extract shouldn't return an error. %s is returned", err)
+		}
+		if extracted.Size() != 20 || tree.Size() != 80 {
+			t.Errorf("sizes are %d and %d, want 20 and 80", extracted.Size(), tree.Size())
+		}
+		for i := 0; i < 100; i++ {
+			_, inTree := tree.Get(i)
+			value, inExtracted := extracted.Get(i)
+			if want := i >= 20 && i < 40; inExtracted != want || inTree == want {
+				t.Errorf("%d is in the extracted tree : %v, in the tree : %v", i, inExtracted, inTree)
+			}
+			if inExtracted && value != i*10 {
+				t.Errorf("extracted value of %d is %d, want %d", i, value, i*10)
+			}
+		}
+		if _, ok := rootOf(extracted).isValid(); !ok {
+			t.Errorf("the extracted tree isn't a valid AVL tree")
+		}
+		if _, ok := rootOf(tree).isValid(); !ok {
+			t.Errorf("the tree isn't a valid AVL tree after Extract")
+		}
+
+		//the extracted tree is a usual tree
+		extracted.PutOne(100, 1000)
+		if extracted.Size() != 21 {
+			t.Errorf("extracted size is %d, want 21", extracted.Size())
+		}
+
+		if _, err := extractOf(tree, Inclusive(3), Inclusive(2)); err != ErrInvalidRange {
+			t.Errorf("Extract[3, 2] should return ErrInvalidRange. %v is returned", err)
+		}
+	})
+}
+
+func TestDeleteFuncAndRetain(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantTree[int, int](v)
+		for i := 0; i < 100; i++ {
+			tree.PutOne(i, i)
+		}
+
+		deletedKeys := make([]int, 0)
+		tree.OnDelete(func(key, value int) {
+			deletedKeys = append(deletedKeys, key)
+		})
+
+		if deleted := tree.DeleteFunc(func(key, value int) bool { return key%2 == 1 }); deleted != 50 {
+			t.Errorf("DeleteFunc deleted %d keys, want 50", deleted)
+		}
+		if deleted := tree.Retain(func(key, value int) bool { return key < 10 }); deleted != 45 {
+			t.Errorf("Retain deleted %d keys, want 45", deleted)
+		}
+		if keys, want := tree.PrintKeys(0), []int{0, 2, 4, 6, 8}; !reflect.DeepEqual(keys, want) {
+			t.Errorf("keys are %v, want %v", keys, want)
+		}
+		if len(deletedKeys) != 95 {
+			t.Errorf("%d delete events, want 95", len(deletedKeys))
+		}
+		if _, ok := rootOf(tree).isValid(); !ok {
+			t.Errorf("tree isn't a valid AVL tree after DeleteFunc")
+		}
+
+		if deleted := tree.DeleteFunc(func(key, value int) bool { return false }); deleted != 0 {
+			t.Errorf("DeleteFunc deleted %d keys, want 0", deleted)
+		}
+	})
+}
+
+// extractOf() calls the Extract() method of the variant of the tree
+func extractOf[K Ordered, V any](tree testTree[K, V], lo, hi Bound[K]) (testTree[K, V], error) {
+	if unsync, ok := tree.(*UnsyncTree[K, V]); ok {
+		return unsync.Extract(lo, hi)
+	}
+	return tree.(*Tree[K, V]).Extract(lo, hi)
+}
+
+func BenchmarkDeleteRange(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		tree := NewUnsyncTree[int, int]()
+		for k := 0; k < 100000; k++ {
+			tree.PutOne(k, k)
+		}
+		b.StartTimer()
+		tree.DeleteRange(Unbounded[int](), Exclusive(50000))
+	}
+}
@@ -0,0 +1,192 @@
+package avlgo
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// floatMaps are the SortedMap implementations with float keys
+var floatMaps = []struct {
+	name string
+	new  func() SortedMap[float64, string]
+}{
+	{"Tree", func() SortedMap[float64, string] { return NewTree[float64, string]() }},
+	{"RBTree", func() SortedMap[float64, string] { return NewRBTree[float64, string]() }},
+	{"BTree", func() SortedMap[float64, string] { return NewBTree[float64, string](2) }},
+	{"SliceMap", func() SortedMap[float64, string] { return NewSliceMap[float64, string]() }},
+	{"ShardedTree", func() SortedMap[float64, string] { return NewShardedTree[float64, string]([]float64{0}, 0) }},
+	{"RCUTree", func() SortedMap[float64, string] { return NewRCUTree[float64, string]() }},
+}
+
+func TestNaNKeys(t *testing.T) {
+	nan := math.NaN()
+	for _, impl := range floatMaps {
+		t.Run(impl.name, func(t *testing.T) {
+			m := impl.new()
+			for _, k := range []float64{math.Inf(-1), -2, 1, 3, math.Inf(1)} {
+				m.PutOne(k, "")
+			}
+
+			if m.PutOne(nan, "nan") {
+				t.Errorf("PutOne(NaN) should return false")
+			}
+			if m.Size() != 5 {
+				t.Errorf("size is %d after PutOne(NaN), want 5", m.Size())
+			}
+			if value, ok := m.Get(nan); ok {
+				t.Errorf("Get(NaN) returns %q, true, want false", value)
+			}
+			if deleted := m.Delete(nan, 1); deleted != 1 || m.Size() != 4 {
+				t.Errorf("Delete(NaN, 1) returns %d with size %d, want 1 and 4", deleted, m.Size())
+			}
+			for _, bounds := range [][2]float64{{nan, 3}, {-2, nan}, {nan, nan}} {
+				if values := m.GetFromTo(bounds[0], bounds[1], true); len(values) != 0 {
+					t.Errorf("GetFromTo(%g, %g) returns %v, want nothing", bounds[0], bounds[1], values)
+				}
+			}
+			var keys []float64
+			m.Ascend(func(key float64, value string) bool {
+				keys = append(keys, key)
+				return true
+			})
+			if want := []float64{math.Inf(-1), -2, 3, math.Inf(1)}; !reflect.DeepEqual(keys, want) {
+				t.Errorf("keys are %v, want %v", keys, want)
+			}
+			if v, ok := m.(validator); ok && !v.isValid() {
+				t.Errorf("the invariants of the map are broken")
+			}
+		})
+	}
+}
+
+func TestNaNKeysInTree(t *testing.T) {
+	nan := math.NaN()
+	tree := NewTree[float64, int]()
+	tree.PutOne(1, 1)
+	tree.PutOne(2, 2)
+
+	if tree.PutWithTTL(nan, 0, 0) {
+		t.Errorf("PutWithTTL(NaN) should return false")
+	}
+	if _, loaded := tree.GetOrPut(nan, 0); loaded || tree.Size() != 2 {
+		t.Errorf("GetOrPut(NaN) should neither load nor put")
+	}
+	called := false
+	tree.Compute(nan, func(old int, ok bool) (int, Op) {
+		called = true
+		return 0, OpDelete
+	})
+	if called || tree.Size() != 2 {
+		t.Errorf("Compute(NaN) shouldn't call fn nor change the tree")
+	}
+	//a batch with a NaN key is rejected as a whole
+	if put, deleted, err := tree.Batch(BatchOp[float64, int]{Key: 3}, BatchOp[float64, int]{Key: nan, Delete: true}); put != 0 || deleted != 0 || !errors.Is(err, ErrNaNKey) {
+		t.Errorf("Batch with a NaN key returns %d, %d, %v, want 0, 0, ErrNaNKey", put, deleted, err)
+	}
+	if tree.Size() != 2 {
+		t.Errorf("size is %d after a rejected batch, want 2", tree.Size())
+	}
+	if root := tree.RootNode.Put(nan, 0); root != tree.RootNode || root.Size() != 2 {
+		t.Errorf("Node.Put(NaN) shouldn't change the tree")
+	}
+	if tree.RootNode.Get(nan) != nil {
+		t.Errorf("Node.Get(NaN) should return nil")
+	}
+	if _, err := tree.GetRange(Inclusive(nan), Unbounded[float64]()); !errors.Is(err, ErrNaNKey) {
+		t.Errorf("GetRange from NaN returns %v, want ErrNaNKey", err)
+	}
+	if _, err := tree.DeleteRange(Unbounded[float64](), Exclusive(nan)); !errors.Is(err, ErrNaNKey) {
+		t.Errorf("DeleteRange to NaN returns %v, want ErrNaNKey", err)
+	}
+	if _, _, err := tree.GetRangePage(Unbounded[float64](), Inclusive(nan), 10, ""); !errors.Is(err, ErrNaNKey) {
+		t.Errorf("GetRangePage to NaN returns %v, want ErrNaNKey", err)
+	}
+	if _, _, err := tree.GetRangePage(Unbounded[float64](), Unbounded[float64](), 10, encodeCursor(nan)); !errors.Is(err, ErrNaNKey) {
+		t.Errorf("GetRangePage after a NaN cursor returns %v, want ErrNaNKey", err)
+	}
+	if entries := KNearest[float64, int](tree, nan, 2); entries != nil {
+		t.Errorf("KNearest(NaN) returns %v, want nothing", entries)
+	}
+
+	if set, err := NewSet(1.0, nan); set != nil || !errors.Is(err, ErrNaNKey) {
+		t.Errorf("NewSet(1, NaN) returns %v, %v, want nil, ErrNaNKey", set, err)
+	}
+	set := newSet(1.0, 2.0)
+	if added, err := set.Add(3, nan); added != 0 || !errors.Is(err, ErrNaNKey) {
+		t.Errorf("Add(3, NaN) returns %d, %v, want 0, ErrNaNKey", added, err)
+	}
+	if set.Contains(nan) || set.Contains(3) || set.Size() != 2 {
+		t.Errorf("a set shouldn't hold NaN")
+	}
+	if _, ok := set.Floor(nan); ok {
+		t.Errorf("Floor(NaN) should return false")
+	}
+	if _, ok := set.Ceiling(nan); ok {
+		t.Errorf("Ceiling(NaN) should return false")
+	}
+
+	//a file holding a NaN key is rejected
+	corrupted := NewTree[float64, int]()
+	corrupted.RootNode = &Node[float64, int]{Key: 1, Next: &Node[float64, int]{Key: nan}}
+	file := filepath.Join(t.TempDir(), "nan.gob")
+	if err := corrupted.Encode(file); err != nil {
+		t.Fatalf("Encode shouldn't return an error. %s is returned", err)
+	}
+	if _, err := Decode[float64, int](file); err == nil {
+		t.Errorf("Decode of a NaN key should return an error")
+	}
+}
+
+func TestSignedZeroKeys(t *testing.T) {
+	negativeZero := math.Copysign(0, -1)
+	for _, impl := range floatMaps {
+		t.Run(impl.name, func(t *testing.T) {
+			m := impl.new()
+			m.PutOne(-1, "-1")
+			m.PutOne(negativeZero, "-0")
+			m.PutOne(1, "1")
+
+			//+0 is the same key : it replaces the value, and the key keeps its sign
+			m.PutOne(0, "+0")
+			if m.Size() != 3 {
+				t.Errorf("size is %d, want 3", m.Size())
+			}
+			for _, zero := range []float64{0, negativeZero} {
+				if value, ok := m.Get(zero); !ok || value != "+0" {
+					t.Errorf("Get(%g) returns %q, %v, want +0, true", zero, value, ok)
+				}
+			}
+			m.Ascend(func(key float64, value string) bool {
+				if key == 0 && !math.Signbit(key) {
+					t.Errorf("the zero key should keep the sign it was first put with")
+				}
+				return true
+			})
+
+			for _, test := range []struct {
+				from, to float64
+				included bool
+				want     []string
+			}{
+				{negativeZero, 1, true, []string{"+0", "1"}},
+				{0, 1, false, nil},
+				{-1, negativeZero, true, []string{"-1", "+0"}},
+				{-1, 0, false, nil},
+			} {
+				if values := m.GetFromTo(test.from, test.to, test.included); !reflect.DeepEqual(values, test.want) {
+					t.Errorf("GetFromTo(%g, %g, %v) returns %v, want %v", test.from, test.to, test.included, values, test.want)
+				}
+			}
+
+			if deleted := m.Delete(0); deleted != 1 || m.Size() != 2 {
+				t.Errorf("Delete(+0) returns %d with size %d, want 1 and 2", deleted, m.Size())
+			}
+			if _, ok := m.Get(negativeZero); ok {
+				t.Errorf("Get(-0) should return false once +0 is deleted")
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package avlgo
+
+// MapValues() returns a new tree with the keys of t and the values fn(key, value)
+// The new tree has the same shape as t, so it's built in O(n) without any rebalancing
+// Only the entries are mapped : TTLs, capacity and observers are not
+func MapValues[K Ordered, V, W any](t AnyTree[K, V], fn func(key K, value V) W) (mapped *Tree[K, W]) {
+	t.read(func(t *core[K, V]) {
+		mapped = &Tree[K, W]{core: mapCore(t, fn)}
+	})
+	return mapped
+}
+
+// Filter() returns a new tree with the entries of t for which pred returns true
+// The entries are read in order, so the new tree is built balanced in O(n) without any rotation
+func Filter[K Ordered, V any](t AnyTree[K, V], pred func(key K, value V) bool) *Tree[K, V] {
+	keys, values := make([]K, 0), make([]V, 0)
+	ascend(t, Unbounded[K](), Unbounded[K](), func(key K, value V) bool {
+		if pred(key, value) {
+			keys = append(keys, key)
+			values = append(values, value)
+		}
+		return true
+	})
+	return newTreeFromSorted(keys, values)
+}
+
+// Fold() calls fn for each entry of the tree, in order, with the result of the previous call (init for the first one),
+// and returns the last result (init for an empty tree)
+func Fold[K Ordered, V, A any](t AnyTree[K, V], init A, fn func(acc A, key K, value V) A) A {
+	ascend(t, Unbounded[K](), Unbounded[K](), func(key K, value V) bool {
+		init = fn(init, key, value)
+		return true
+	})
+	return init
+}
+
+// FoldRange() acts like Fold() for the entries between the bounds lo and hi
+func FoldRange[K Ordered, V, A any](t AnyTree[K, V], lo, hi Bound[K], init A, fn func(acc A, key K, value V) A) (A, error) {
+	if err := validRange(lo, hi); err != nil {
+		return init, err
+	}
+	ascend(t, lo, hi, func(key K, value V) bool {
+		init = fn(init, key, value)
+		return true
+	})
+	return init, nil
+}
+
+// Reduce() acts like Fold() with the first value of the tree as the initial result
+// ok is false for an empty tree
+func Reduce[K Ordered, V any](t AnyTree[K, V], fn func(acc V, key K, value V) V) (result V, ok bool) {
+	ascend(t, Unbounded[K](), Unbounded[K](), func(key K, value V) bool {
+		if !ok {
+			result, ok = value, true
+		} else {
+			result = fn(result, key, value)
+		}
+		return true
+	})
+	return result, ok
+}
+
+// ascend() calls fn for each key/value between the bounds lo and hi, in order, until fn returns false
+// (the bounds must be valid). The tree is read at once, so fn must not modify it
+func ascend[K Ordered, V any](t AnyTree[K, V], lo, hi Bound[K], fn func(key K, value V) bool) {
+	t.read(func(t *core[K, V]) {
+		t.ascend(lo, hi, fn)
+	})
+}
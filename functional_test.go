@@ -0,0 +1,127 @@
+package avlgo
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type employee struct {
+	name   string
+	salary int
+}
+
+func TestMapValues(t *testing.T) {
+	tree := NewTree[int, employee]()
+	for i := 0; i < 50; i++ {
+		tree.PutOne(i, employee{name: "e" + strconv.Itoa(i), salary: i * 100})
+	}
+
+	salaries := MapValues[int, employee](tree, func(key int, value employee) int {
+		return value.salary
+	})
+	if salaries.Size() != 50 || salaries.Depth() != tree.Depth() {
+		t.Errorf("size and depth are %d and %d, want 50 and %d", salaries.Size(), salaries.Depth(), tree.Depth())
+	}
+	for i := 0; i < 50; i++ {
+		if salary, ok := salaries.Get(i); !ok || salary != i*100 {
+			t.Errorf("salary of %d is %d, %v, want %d, true", i, salary, ok, i*100)
+		}
+	}
+	if _, ok := salaries.RootNode.isValid(); !ok {
+		t.Errorf("mapped tree isn't a valid AVL tree")
+	}
+
+	//the trees are independent
+	salaries.Delete(0)
+	if tree.Size() != 50 {
+		t.Errorf("size is %d, want 50", tree.Size())
+	}
+	if empty := MapValues[int, int](NewTree[int, int](), func(key, value int) string { return "" }); empty.Size() != 0 {
+		t.Errorf("size is %d, want 0", empty.Size())
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tree := NewTree[int, int]()
+	for i := 0; i < 100; i++ {
+		tree.PutOne(i, i)
+	}
+
+	filtered := Filter[int, int](tree, func(key, value int) bool { return value%10 == 0 })
+	if keys, want := filtered.PrintKeys(0), []int{0, 10, 20, 30, 40, 50, 60, 70, 80, 90}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys are %v, want %v", keys, want)
+	}
+	if filtered.Size() != 10 || tree.Size() != 100 {
+		t.Errorf("sizes are %d and %d, want 10 and 100", filtered.Size(), tree.Size())
+	}
+	if _, ok := filtered.RootNode.isValid(); !ok {
+		t.Errorf("filtered tree isn't a valid AVL tree")
+	}
+	if none := Filter[int, int](tree, func(key, value int) bool { return false }); none.Size() != 0 {
+		t.Errorf("size is %d, want 0", none.Size())
+	}
+}
+
+func TestFoldAndReduce(t *testing.T) {
+	tree := NewTree[int, int]()
+	if _, ok := Reduce[int, int](tree, func(acc, key, value int) int { return acc + value }); ok {
+		t.Errorf("Reduce on an empty tree should return false")
+	}
+	for i := 1; i <= 10; i++ {
+		tree.PutOne(i, i)
+	}
+
+	if sum, ok := Reduce[int, int](tree, func(acc, key, value int) int { return acc + value }); !ok || sum != 55 {
+		t.Errorf("sum is %d, %v, want 55, true", sum, ok)
+	}
+	joined := Fold[int, int](tree, "", func(acc string, key, value int) string { return acc + strconv.Itoa(key) })
+	if joined != "12345678910" {
+		t.Errorf("joined keys are %q, want %q", joined, "12345678910")
+	}
+	count, err := FoldRange[int, int](tree, Exclusive(3), Inclusive(7), 0, func(acc, key, value int) int { return acc + 1 })
+	if err != nil || count != 4 {
+		t.Errorf("FoldRange(3, 7] is %d, %v, want 4, nil", count, err)
+	}
+	if _, err := FoldRange[int, int](tree, Inclusive(7), Inclusive(3), 0, func(acc, key, value int) int { return acc }); err != ErrInvalidRange {
+		t.Errorf("FoldRange[7, 3] should return ErrInvalidRange. %v is returned", err)
+	}
+}
+
+func TestFunctionsOnUnsyncTree(t *testing.T) {
+	tree := NewUnsyncTree[int, int]()
+	for i := 0; i < 10; i++ {
+		tree.PutOne(i, i*10)
+	}
+
+	filtered := Filter[int, int](tree, func(key, value int) bool { return key%2 == 0 })
+	if keys, want := filtered.PrintKeys(0), []int{0, 2, 4, 6, 8}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys are %v, want %v", keys, want)
+	}
+	if sum := Fold[int, int](tree, 0, func(acc, key, value int) int { return acc + value }); sum != 450 {
+		t.Errorf("sum is %d, want 450", sum)
+	}
+	if key, _, ok := Nearest[int, int](tree, 42); !ok || key != 9 {
+		t.Errorf("Nearest(42) returns %d, %v, want 9, true", key, ok)
+	}
+	if Equal[int, int](tree, filtered, func(x, y int) bool { return x == y }) {
+		t.Errorf("Equal should be false")
+	}
+	if changes := Diff[int, int](filtered, tree, func(x, y int) bool { return x == y }); len(changes) != 5 {
+		t.Errorf("Diff returns %d changes, want 5", len(changes))
+	}
+	if Hash[int, int](tree, nil) != Hash[int, int](MapValues[int, int](tree, func(key, value int) int { return value }), nil) {
+		t.Errorf("Hash should not depend on the tree type")
+	}
+
+	words := NewUnsyncTree[string, int]()
+	for _, word := range []string{"tea", "ten", "to", "tree"} {
+		words.PutOne(word, len(word))
+	}
+	if count := CountPrefix[string, int](words, "te"); count != 2 {
+		t.Errorf("CountPrefix is %d, want 2", count)
+	}
+	if deleted := DeletePrefix[string, int](words, "t"); deleted != 4 || words.Size() != 0 {
+		t.Errorf("DeletePrefix returns %d (size %d), want 4 (size 0)", deleted, words.Size())
+	}
+}
@@ -0,0 +1,192 @@
+package avlgo
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// model is the reference implementation the Tree is compared against :
+// a map for the contents and a sorted slice for the ordering
+type model struct {
+	values map[int]int
+	keys   []int
+}
+
+func newModel() *model {
+	return &model{values: make(map[int]int)}
+}
+
+func (m *model) put(key, value int) {
+	if _, ok := m.values[key]; !ok {
+		i := sort.SearchInts(m.keys, key)
+		m.keys = append(m.keys, 0)
+		copy(m.keys[i+1:], m.keys[i:])
+		m.keys[i] = key
+	}
+	m.values[key] = value
+}
+
+func (m *model) delete(key int) int {
+	if _, ok := m.values[key]; !ok {
+		return 0
+	}
+	delete(m.values, key)
+	i := sort.SearchInts(m.keys, key)
+	m.keys = append(m.keys[:i], m.keys[i+1:]...)
+	return 1
+}
+
+func (m *model) fromTo(from, to int, boundsIncluded bool) (values []int) {
+	for _, k := range m.keys {
+		if (k > from && k < to) || ((k == from || k == to) && boundsIncluded) {
+			values = append(values, m.values[k])
+		}
+	}
+	return values
+}
+
+func (m *model) orderedValues() (values []int) {
+	for _, k := range m.keys {
+		values = append(values, m.values[k])
+	}
+	return values
+}
+
+// checkNode() walks the subtree of n, checking parent links, ordering (keys must lie in ]lo, hi[)
+// and the AVL invariant. It returns the depth of the subtree
+func checkNode(t *testing.T, n *Node[int, int], parent *Node[int, int], lo, hi *int) int {
+	t.Helper()
+	if n == nil {
+		return 0
+	}
+	if n.parent != parent {
+		t.Fatalf("node %d has a wrong parent link", n.Key)
+	}
+	if (lo != nil && n.Key <= *lo) || (hi != nil && n.Key >= *hi) {
+		t.Fatalf("node %d breaks the ordering", n.Key)
+	}
+	previousDepth := checkNode(t, n.Previous, n, lo, &n.Key)
+	nextDepth := checkNode(t, n.Next, n, &n.Key, hi)
+	if balance := nextDepth - previousDepth; balance < -1 || balance > 1 {
+		t.Fatalf("node %d is unbalanced (%d)", n.Key, balance)
+	}
+	if previousDepth > nextDepth {
+		return 1 + previousDepth
+	}
+	return 1 + nextDepth
+}
+
+// checkTree() compares the whole tree with the model
+func checkTree(t *testing.T, tree testTree[int, int], m *model) {
+	t.Helper()
+	checkNode(t, rootOf(tree), nil, nil, nil)
+	if rootOf(tree) != nil {
+		if _, ok := rootOf(tree).isValid(); !ok {
+			t.Fatalf("isValid() rejects a valid tree")
+		}
+	}
+	if tree.Size() != len(m.keys) {
+		t.Fatalf("Tree size is %d, want %d", tree.Size(), len(m.keys))
+	}
+	if keys := tree.PrintKeys(0); !reflect.DeepEqual(keys, m.keys) && len(keys)+len(m.keys) != 0 {
+		t.Fatalf("keys are %v, want %v", keys, m.keys)
+	}
+	if values, want := tree.PrintValues(0), m.orderedValues(); !reflect.DeepEqual(values, want) && len(values)+len(want) != 0 {
+		t.Fatalf("values are %v, want %v", values, want)
+	}
+	for k, v := range m.values {
+		if value, ok := tree.Get(k); !ok || value != v {
+			t.Fatalf("Get(%d) returns %d, %v, want %d, true", k, value, ok, v)
+		}
+	}
+}
+
+// FuzzTreeOperations decodes the input into a sequence of operations (2 bytes each),
+// runs them on a Tree, on an UnsyncTree and on a model, and checks they agree after every step
+func FuzzTreeOperations(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0, 1, 0, 2, 0, 3, 0, 4, 0, 5, 0, 6, 0, 7, 1, 4, 1, 1})
+	f.Add([]byte{0, 10, 0, 5, 0, 15, 0, 3, 0, 7, 0, 20, 0, 6, 1, 5, 1, 10})
+	f.Add([]byte{0, 40, 0, 20, 0, 60, 0, 10, 0, 30, 0, 50, 0, 70, 0, 25, 0, 35, 0, 55, 1, 40, 2, 20, 3, 50})
+
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		trees := []testTree[int, int]{NewTree[int, int](), NewUnsyncTree[int, int]()}
+		m := newModel()
+
+		for i := 0; i+1 < len(ops); i += 2 {
+			key := int(ops[i+1] % 64)
+			wantDeleted := 0
+			switch ops[i] % 4 {
+			case 0: //put
+				m.put(key, i)
+			case 1: //delete
+				wantDeleted = m.delete(key)
+			}
+
+			for _, tree := range trees {
+				switch ops[i] % 4 {
+				case 0: //put
+					tree.PutOne(key, i)
+				case 1: //delete
+					if deleted := tree.Delete(key); deleted != wantDeleted {
+						t.Fatalf("Delete(%d) returns %d, want %d", key, deleted, wantDeleted)
+					}
+				case 2: //get
+					value, ok := tree.Get(key)
+					want, wantOk := m.values[key]
+					if ok != wantOk || value != want {
+						t.Fatalf("Get(%d) returns %d, %v, want %d, %v", key, value, ok, want, wantOk)
+					}
+				case 3: //range
+					to := key + int(ops[i]/4)%16
+					for _, boundsIncluded := range []bool{true, false} {
+						values, want := tree.GetFromTo(key, to, boundsIncluded), m.fromTo(key, to, boundsIncluded)
+						if !reflect.DeepEqual(values, want) && len(values)+len(want) != 0 {
+							t.Fatalf("GetFromTo(%d, %d, %v) returns %v, want %v", key, to, boundsIncluded, values, want)
+						}
+					}
+				}
+				checkTree(t, tree, m)
+			}
+		}
+	})
+}
+
+// FuzzDecode checks that Decode() never panics and only returns valid trees
+func FuzzDecode(f *testing.F) {
+	dir := f.TempDir()
+	for _, keys := range [][]int{{}, {1}, {1, 2}, {2, 1}, {0, 1, 2, 3, 4, 5, 6, 7, 8, 9}} {
+		tree := NewTree[int, int]()
+		for _, k := range keys {
+			tree.PutOne(k, k)
+		}
+		file := filepath.Join(dir, "seed.gob")
+		if err := tree.Encode(file); err != nil {
+			f.Fatal(err)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		file := filepath.Join(t.TempDir(), "fuzz.gob")
+		if err := os.WriteFile(file, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		tree, err := Decode[int, int](file)
+		if err != nil {
+			return
+		}
+		m := newModel()
+		for _, n := range tree.Print(0) {
+			m.put(n.Key, n.Value)
+		}
+		checkTree(t, tree, m)
+	})
+}
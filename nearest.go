@@ -0,0 +1,93 @@
+package avlgo
+
+// Number is the constraint of the keys having a distance between them : the integers and the floats
+type Number interface {
+	Integer | Float
+}
+
+// Nearest() returns the entry of the key closest to x (ok is false for an empty tree or a NaN x)
+// On a tie, between a key below x and a key above x at the same distance, the smaller key is returned
+// It is a function rather than a method of Tree because it only applies to numeric keys
+func Nearest[K Number, V any](t AnyTree[K, V], x K) (key K, value V, ok bool) {
+	entries := KNearest(t, x, 1)
+	if len(entries) == 0 {
+		return key, value, false
+	}
+	return entries[0].Key, entries[0].Value, true
+}
+
+// KNearest() returns the entries of the k keys closest to x, from the closest to the farthest
+// (fewer if the tree holds fewer than k keys, none for a NaN x). Keys at the same distance are returned
+// smaller first, like in Nearest()
+// It finds the keys around x, then walks outward in both directions : it runs in O(log(n) + k)
+func KNearest[K Number, V any](t AnyTree[K, V], x K, k int) (entries []Entry[K, V]) {
+	t.read(func(t *core[K, V]) {
+		entries = kNearest(t, x, k)
+	})
+	return entries
+}
+
+// kNearest() returns the entries of the k keys closest to x, like KNearest() (the tree must be read-locked)
+func kNearest[K Number, V any](t *core[K, V], x K, k int) []Entry[K, V] {
+	if t.RootNode == nil || k <= 0 || isNaN(x) {
+		return nil
+	}
+	below, above := t.RootNode.floor(x), t.RootNode.ceiling(x)
+	if below != nil && below == above { //x is a key of the tree : it is the first one walked below
+		above = above.successor()
+	}
+
+	//k may be much bigger than the tree : it doesn't size the slice
+	size := k
+	if t.count < size {
+		size = t.count
+	}
+	entries := make([]Entry[K, V], 0, size)
+	for len(entries) < k && (below != nil || above != nil) {
+		if above == nil || (below != nil && nearer(x, below.Key, above.Key)) {
+			entries = append(entries, Entry[K, V]{Key: below.Key, Value: below.Value})
+			below = below.predecessor()
+		} else {
+			entries = append(entries, Entry[K, V]{Key: above.Key, Value: above.Value})
+			above = above.successor()
+		}
+	}
+	return entries
+}
+
+// WithinDistance() returns, in key order, the entries whose keys are at a distance of at most d from x
+// (between x-d and x+d, both included). The bounds are clamped to the range of K : x-d never wraps around
+// for integer keys. It returns nothing for a negative d, or a NaN x or d
+func WithinDistance[K Number, V any](t AnyTree[K, V], x, d K) []Entry[K, V] {
+	if d < 0 || isNaN(x) || isNaN(d) {
+		return nil
+	}
+	lo, hi := Unbounded[K](), Unbounded[K]()
+	if low := x - d; low <= x { //false if the subtraction wrapped around (or gave NaN from infinities)
+		lo = Inclusive(low)
+	}
+	if high := x + d; high >= x {
+		hi = Inclusive(high)
+	}
+	entries := make([]Entry[K, V], 0)
+	ascend(t, lo, hi, func(key K, value V) bool { //lo <= x <= hi : the range is valid
+		entries = append(entries, Entry[K, V]{Key: key, Value: value})
+		return true
+	})
+	return entries
+}
+
+// nearer() returns true if below is at least as near to x as above (below <= x <= above)
+// The distances of integer keys are computed in uint64 : they never overflow, even between the extreme keys of int64
+func nearer[K Number](x, below, above K) bool {
+	if isFloat[K]() {
+		return x-below <= above-x
+	}
+	return uint64(x)-uint64(below) <= uint64(above)-uint64(x)
+}
+
+// isFloat() returns true if K is a float type
+func isFloat[K Number]() bool {
+	var one K = 1
+	return one/2 != 0
+}
@@ -0,0 +1,167 @@
+package avlgo
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func keysOf[K Ordered, V any](entries []Entry[K, V]) (keys []K) {
+	for _, e := range entries {
+		keys = append(keys, e.Key)
+	}
+	return keys
+}
+
+func TestNearest(t *testing.T) {
+	tree := NewTree[int, string]()
+	if _, _, ok := Nearest[int, string](tree, 5); ok {
+		t.Errorf("Nearest on an empty tree should return false")
+	}
+	for _, k := range []int{10, 20, 30} {
+		tree.PutOne(k, "")
+	}
+
+	for _, test := range []struct{ x, want int }{
+		{-100, 10}, {10, 10}, {14, 10}, {15, 10}, {16, 20}, {25, 20}, {29, 30}, {1000, 30},
+	} {
+		if key, _, ok := Nearest[int, string](tree, test.x); !ok || key != test.want {
+			t.Errorf("Nearest(%d) returns %d, %v, want %d, true", test.x, key, ok, test.want)
+		}
+	}
+
+	//the distances don't overflow between the extreme keys
+	extremes := NewTree[int8, int8]()
+	extremes.PutOne(math.MinInt8, 0)
+	extremes.PutOne(math.MaxInt8, 0)
+	if key, _, _ := Nearest[int8, int8](extremes, 0); key != math.MaxInt8 {
+		t.Errorf("Nearest(0) returns %d, want %d", key, math.MaxInt8)
+	}
+	if key, _, _ := Nearest[int8, int8](extremes, -1); key != math.MinInt8 {
+		t.Errorf("Nearest(-1) returns %d, want %d", key, math.MinInt8)
+	}
+	unsigned := NewTree[uint, int]()
+	unsigned.PutOne(0, 0)
+	unsigned.PutOne(math.MaxUint, 0)
+	if key, _, _ := Nearest[uint, int](unsigned, math.MaxUint/2+1); key != math.MaxUint {
+		t.Errorf("Nearest(MaxUint/2+1) returns %d, want %d", key, uint(math.MaxUint))
+	}
+
+	floats := NewTree[float64, string]()
+	for _, k := range []float64{-1.5, 0.25, 2} {
+		floats.PutOne(k, "")
+	}
+	if key, _, _ := Nearest[float64, string](floats, -0.625); key != -1.5 {
+		t.Errorf("Nearest(-0.625) returns %g, want -1.5 (tie)", key)
+	}
+	if key, _, _ := Nearest[float64, string](floats, 1.2); key != 2 {
+		t.Errorf("Nearest(1.2) returns %g, want 2", key)
+	}
+	if _, _, ok := Nearest[float64, string](floats, math.NaN()); ok {
+		t.Errorf("Nearest(NaN) should return false")
+	}
+}
+
+func TestKNearest(t *testing.T) {
+	tree := NewTree[int, int]()
+	for _, k := range []int{1, 3, 4, 8, 9, 12} {
+		tree.PutOne(k, k*10)
+	}
+	for _, test := range []struct {
+		x, k int
+		want []int
+	}{
+		{6, 3, []int{4, 8, 3}},
+		{4, 4, []int{4, 3, 1, 8}},
+		{6, 1, []int{4}},
+		{0, 2, []int{1, 3}},
+		{20, 2, []int{12, 9}},
+		{6, 10, []int{4, 8, 3, 9, 1, 12}},
+		{6, 0, nil},
+	} {
+		if keys := keysOf(KNearest[int, int](tree, test.x, test.k)); !reflect.DeepEqual(keys, test.want) {
+			t.Errorf("KNearest(%d, %d) returns %v, want %v", test.x, test.k, keys, test.want)
+		}
+	}
+	if entries := KNearest[int, int](tree, 9, 1); entries[0].Value != 90 {
+		t.Errorf("KNearest(9, 1) value is %d, want 90", entries[0].Value)
+	}
+	//k much bigger than the tree doesn't allocate for k entries
+	if entries := KNearest[int, int](tree, 6, math.MaxInt); len(entries) != 6 || cap(entries) != 6 {
+		t.Errorf("KNearest(6, MaxInt) returns %d entries (capacity %d), want 6", len(entries), cap(entries))
+	}
+
+	//against a sort of all the keys by distance
+	r := rand.New(rand.NewSource(48))
+	tree = NewTree[int, int]()
+	var keys []int
+	for i := 0; i < 500; i++ {
+		k := r.Intn(10000)
+		if _, ok := tree.Get(k); !ok {
+			keys = append(keys, k)
+		}
+		tree.PutOne(k, k)
+	}
+	for i := 0; i < 100; i++ {
+		x, k := r.Intn(11000)-500, r.Intn(20)
+		want := append([]int(nil), keys...)
+		sort.Slice(want, func(i, j int) bool {
+			di, dj := abs(want[i]-x), abs(want[j]-x)
+			return di < dj || (di == dj && want[i] < want[j])
+		})
+		if got := keysOf(KNearest[int, int](tree, x, k)); len(got) != k || (k > 0 && !reflect.DeepEqual(got, want[:k])) {
+			t.Fatalf("KNearest(%d, %d) returns %v, want %v", x, k, got, want[:k])
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func TestWithinDistance(t *testing.T) {
+	tree := NewTree[int, int]()
+	for _, k := range []int{1, 3, 4, 8, 9, 12} {
+		tree.PutOne(k, k)
+	}
+	if keys := keysOf(WithinDistance[int, int](tree, 6, 2)); !reflect.DeepEqual(keys, []int{4, 8}) {
+		t.Errorf("WithinDistance(6, 2) returns %v, want [4 8]", keys)
+	}
+	if keys := keysOf(WithinDistance[int, int](tree, 3, 0)); !reflect.DeepEqual(keys, []int{3}) {
+		t.Errorf("WithinDistance(3, 0) returns %v, want [3]", keys)
+	}
+	if entries := WithinDistance[int, int](tree, 6, -1); entries != nil {
+		t.Errorf("WithinDistance(6, -1) returns %v, want nothing", entries)
+	}
+
+	//the bounds are clamped instead of wrapping around
+	unsigned := NewTree[uint8, int]()
+	for _, k := range []uint8{0, 5, 250, 255} {
+		unsigned.PutOne(k, 0)
+	}
+	if keys := keysOf(WithinDistance[uint8, int](unsigned, 3, 10)); !reflect.DeepEqual(keys, []uint8{0, 5}) {
+		t.Errorf("WithinDistance(3, 10) returns %v, want [0 5]", keys)
+	}
+	if keys := keysOf(WithinDistance[uint8, int](unsigned, 252, 10)); !reflect.DeepEqual(keys, []uint8{250, 255}) {
+		t.Errorf("WithinDistance(252, 10) returns %v, want [250 255]", keys)
+	}
+
+	floats := NewTree[float64, int]()
+	for _, k := range []float64{math.Inf(-1), -1, 0.5, math.Inf(1)} {
+		floats.PutOne(k, 0)
+	}
+	if keys := keysOf(WithinDistance[float64, int](floats, 0, 1)); !reflect.DeepEqual(keys, []float64{-1, 0.5}) {
+		t.Errorf("WithinDistance(0, 1) returns %v, want [-1 0.5]", keys)
+	}
+	if keys := keysOf(WithinDistance[float64, int](floats, math.Inf(1), math.Inf(1))); len(keys) != 4 {
+		t.Errorf("WithinDistance(+Inf, +Inf) returns %v, want all the keys", keys)
+	}
+	if entries := WithinDistance[float64, int](floats, math.NaN(), 1); entries != nil {
+		t.Errorf("WithinDistance(NaN, 1) returns %v, want nothing", entries)
+	}
+}
@@ -5,6 +5,7 @@ type Node[K Ordered, V any] struct {
 	Key                    K           // Key of the Node must be ordered
 	Value                  V           // Value of the Node can be anything
 	parent, Previous, Next *Node[K, V] // parent, Previous and Next are references to other Node in the Tree
+	depth                  int         // depth of the subtree of the node, kept up to date by the rebalancing (not encoded)
 }
 
 // affectParent() is a method used to re-affect the Parent Node of the children
@@ -18,7 +19,7 @@ func (n *Node[K, V]) affectParentToChildren() bool {
 	}
 	if n.Next != nil {
 		n.Next.parent = n
-		nextOk = n.Previous.affectParentToChildren()
+		nextOk = n.Next.affectParentToChildren()
 	}
 	return previousOk && nextOk
 }
@@ -37,7 +38,11 @@ func (n *Node[K, V]) Size() (size int) {
 
 // Depth() returns the depth of the tree from this node
 // it returns 1 + the biggest depth of its children
+// The depth of the nodes of a Tree is kept up to date while rebalancing, so it's only computed for a node built by hand
 func (n *Node[K, V]) Depth() int {
+	if n.depth > 0 {
+		return n.depth
+	}
 
 	previousDepth := 0
 	if n.Previous != nil {
@@ -85,28 +90,62 @@ func (n *Node[K, V]) Print(wantedDepth, actualDepth uint) (nodes []*Node[K, V])
 }
 
 // Put() add a new Node in the tree, preserving the order and the balance of the Tree
+// A NaN key is not put : the tree is left unchanged and its root node is returned
 func (n *Node[K, V]) Put(key K, value V) (newRootNode *Node[K, V]) {
-	switch {
-	case key > n.Key: //key is bigger than the n.Key
-		if n.Next != nil { //delegates to its Next (if exist)
-			return n.Next.Put(key, value)
-		}
-		//otherwise : create a new Node and affect to its next
-		n.Next = &Node[K, V]{Key: key, parent: n, Value: value}
-		return n.balance()
+	return n.PutTraced(key, value, nil)
+}
 
-	case key < n.Key: //key is smaller than the n.Key
-		if n.Previous != nil { //delegates to its Previous (if exist)
-			return n.Previous.Put(key, value)
+// PutTraced() acts like Put(), reporting its steps to tracer (nil for none), like a Tree with a tracer (see Tree.SetTracer())
+func (n *Node[K, V]) PutTraced(key K, value V, tracer Tracer[K, V]) (newRootNode *Node[K, V]) {
+	if isNaN(key) {
+		return n.RootNode()
+	}
+	p := probeOf(tracer)
+	found, parent := n.search(key, p)
+	if found == nil {
+		return parent.insertChild(key, value, p)
+	}
+	//key is already present so replace the Value
+	found.Value = value
+	p.trace(TraceReplace, key, found)
+	return n.RootNode()
+}
+
+// search() search the key in the node subtree. It returns the node of the key if present (found),
+// otherwise the node under which a node for the key should be added (parent)
+// The key must not be NaN : it would be "found" in the first visited node
+// Each visited node is traced by p (if not nil)
+func (n *Node[K, V]) search(key K, p *probe[K, V]) (found, parent *Node[K, V]) {
+	for {
+		p.trace(TraceDescend, n.Key, n)
+		switch {
+		case key > n.Key:
+			if n.Next == nil {
+				return nil, n
+			}
+			n = n.Next
+		case key < n.Key:
+			if n.Previous == nil {
+				return nil, n
+			}
+			n = n.Previous
+		default:
+			return n, nil
 		}
-		//otherwise : create a new Node and affect to its previiys
-		n.Previous = &Node[K, V]{Key: key, parent: n, Value: value}
-		return n.balance()
+	}
+}
 
-	default: //key is the same than the n.Key so replace the Value
-		n.Value = value
-		return n.RootNode()
+// insertChild() adds a new Node for the key as a child of n, preserving the balance of the Tree,
+// and returns the new root node. n must be the parent returned by search()
+// The insertion and the rebalancing are counted and traced by p (if not nil)
+func (n *Node[K, V]) insertChild(key K, value V, p *probe[K, V]) (newRootNode *Node[K, V]) {
+	if key > n.Key {
+		n.Next = &Node[K, V]{Key: key, parent: n, Value: value, depth: 1}
+	} else {
+		n.Previous = &Node[K, V]{Key: key, parent: n, Value: value, depth: 1}
 	}
+	p.trace(TraceInsertLeaf, key, n)
+	return n.balance(p)
 }
 
 // RootNode returns the root node of the tree
@@ -121,23 +160,47 @@ func (n *Node[K, V]) RootNode() *Node[K, V] {
 // getBalance() returns the difference between next depth and previous depth
 // A node will be balanced if this difference is -1, 0 or +1
 func (n *Node[K, V]) getBalance() int {
+	return n.Next.getDepth() - n.Previous.getDepth()
+}
+
+// getDepth() returns the depth of the node (0 for a nil node)
+func (n *Node[K, V]) getDepth() int {
+	if n == nil {
+		return 0
+	}
+	return n.Depth()
+}
+
+// updateDepth() computes the depth of the node from the depth of its children
+func (n *Node[K, V]) updateDepth() {
+	n.depth = 1 + n.Previous.getDepth()
+	if nextDepth := n.Next.getDepth(); nextDepth >= n.depth {
+		n.depth = 1 + nextDepth
+	}
+}
 
-	previousBalance := 0
-	nextBalance := 0
+// resetDepths() computes the depth of every node of the subtree (after decoding, the depths are unknown)
+func (n *Node[K, V]) resetDepths() int {
+	previousDepth, nextDepth := 0, 0
 	if n.Previous != nil {
-		previousBalance = n.Previous.Depth()
+		previousDepth = n.Previous.resetDepths()
 	}
 	if n.Next != nil {
-		nextBalance = n.Next.Depth()
+		nextDepth = n.Next.resetDepths()
 	}
-
-	return nextBalance - previousBalance
+	n.depth = 1 + previousDepth
+	if nextDepth > previousDepth {
+		n.depth = 1 + nextDepth
+	}
+	return n.depth
 }
 
 // balance() balance a node. If the node is unbalanced, it will perform one (or two) rotation
 // and returns the new root node
-func (n *Node[K, V]) balance() *Node[K, V] {
+// The rotations and the climbs are counted and traced by p (if not nil)
+func (n *Node[K, V]) balance(p *probe[K, V]) *Node[K, V] {
 
+	n.updateDepth()
 	balance := n.getBalance()
 
 	//case of balanced node : recursive call to balance() to its parent
@@ -145,26 +208,45 @@ func (n *Node[K, V]) balance() *Node[K, V] {
 		if n.parent == nil {
 			return n
 		}
-		return n.parent.balance()
+		p.climbed()
+		return n.parent.balance(p)
+	}
+	n.rotate(balance, p)
+
+	//recursive balance on parent
+	if n.parent == nil {
+		return n
 	}
+	return n.parent.balance(p)
+
+}
+
+// rotate() performs one (or two) rotation on an unbalanced node, depending on its balance,
+// and counts and traces it with p (if not nil). The node becomes a child of the new root of its subtree
+func (n *Node[K, V]) rotate(balance int, p *probe[K, V]) {
 	if balance > 1 { //unbalanced node with deeper Next
-		if n.Next.getBalance() < 0 { //double rotation (to avoir infinite rotation)
-			n.Next.rotateRight()
+		if next := n.Next; next.getBalance() < 0 { //double rotation (to avoir infinite rotation)
+			p.rotated(rotationDouble)
+			p.trace(TraceDoubleRotation, n.Key, n)
+			next.rotateRight()
+			p.trace(TraceRotateRight, next.Key, n)
+		} else {
+			p.rotated(rotationLeft)
 		}
 		n.rotateLeft()
+		p.trace(TraceRotateLeft, n.Key, n)
 	} else if balance < -1 { //unbalanced node with deeper Previous
-		if n.Previous.getBalance() > 0 { //double rotation (to avoir infinite rotation)
-			n.Previous.rotateLeft()
+		if previous := n.Previous; previous.getBalance() > 0 { //double rotation (to avoir infinite rotation)
+			p.rotated(rotationDouble)
+			p.trace(TraceDoubleRotation, n.Key, n)
+			previous.rotateLeft()
+			p.trace(TraceRotateLeft, previous.Key, n)
+		} else {
+			p.rotated(rotationRight)
 		}
 		n.rotateRight()
+		p.trace(TraceRotateRight, n.Key, n)
 	}
-
-	//recursive balance on parent
-	if n.parent == nil {
-		return n
-	}
-	return n.parent.balance()
-
 }
 
 // rotateRight() rotates the node to the right
@@ -190,6 +272,9 @@ func (n *Node[K, V]) rotateRight() {
 		n.Previous = nil
 	}
 	n.parent.Next = n
+
+	n.updateDepth()
+	n.parent.updateDepth()
 }
 
 // rotateRight() rotates the node to the left
@@ -214,11 +299,17 @@ func (n *Node[K, V]) rotateLeft() {
 		n.Next = nil
 	}
 	n.parent.Previous = n
+
+	n.updateDepth()
+	n.parent.updateDepth()
 }
 
 // GetFromTo() search in the node the value of the key between from and to and returns them
 func (n *Node[K, V]) GetFromTo(from, to K, boundsIncluded bool) []*Node[K, V] {
 	nodes := make([]*Node[K, V], 0)
+	if isNaN(from) || isNaN(to) { //the range is empty
+		return nodes
+	}
 	if n.Key > from && n.Previous != nil {
 		nodes = append(nodes, n.Previous.GetFromTo(from, to, boundsIncluded)...)
 	}
@@ -234,8 +325,93 @@ func (n *Node[K, V]) GetFromTo(from, to K, boundsIncluded bool) []*Node[K, V] {
 	return nodes
 }
 
-// Get() search in the node the value of the key and returns it if present
+// floor() returns the node of the subtree with the biggest key smaller or equal to key (nil if none)
+func (n *Node[K, V]) floor(key K) (found *Node[K, V]) {
+	if isNaN(key) {
+		return nil
+	}
+	for n != nil {
+		switch {
+		case key < n.Key:
+			n = n.Previous
+		case key > n.Key:
+			found, n = n, n.Next
+		default:
+			return n
+		}
+	}
+	return found
+}
+
+// ceiling() returns the node of the subtree with the smallest key bigger or equal to key (nil if none)
+func (n *Node[K, V]) ceiling(key K) (found *Node[K, V]) {
+	if isNaN(key) {
+		return nil
+	}
+	for n != nil {
+		switch {
+		case key > n.Key:
+			n = n.Next
+		case key < n.Key:
+			found, n = n, n.Previous
+		default:
+			return n
+		}
+	}
+	return found
+}
+
+// ascend() calls fn for each node of the subtree with a key between the bounds lo and hi, in order, until fn returns false
+// It returns false if fn stopped the walk
+func (n *Node[K, V]) ascend(lo, hi Bound[K], fn func(node *Node[K, V]) bool) bool {
+	if n.Previous != nil && (lo.kind == boundUnbounded || n.Key > lo.key) {
+		if !n.Previous.ascend(lo, hi, fn) {
+			return false
+		}
+	}
+	if lo.admitsAbove(n.Key) && hi.admitsBelow(n.Key) {
+		if !fn(n) {
+			return false
+		}
+	}
+	if n.Next != nil && (hi.kind == boundUnbounded || n.Key < hi.key) {
+		return n.Next.ascend(lo, hi, fn)
+	}
+	return true
+}
+
+// mapNode() returns a copy of the subtree of the node, with the same shape, attached to parent
+// The value of each copied node is fn(key, value)
+func mapNode[K Ordered, V, W any](n *Node[K, V], parent *Node[K, W], fn func(key K, value V) W) *Node[K, W] {
+	c := &Node[K, W]{Key: n.Key, Value: fn(n.Key, n.Value), parent: parent, depth: n.depth}
+	if n.Previous != nil {
+		c.Previous = mapNode(n.Previous, c, fn)
+	}
+	if n.Next != nil {
+		c.Next = mapNode(n.Next, c, fn)
+	}
+	return c
+}
+
+// newBalancedNode() builds a balanced tree from ordered keys and values and returns its root node
+// (nil if there is no key). It runs in O(n) without any rotation
+func newBalancedNode[K Ordered, V any](keys []K, values []V, parent *Node[K, V]) *Node[K, V] {
+	if len(keys) == 0 {
+		return nil
+	}
+	middle := len(keys) / 2
+	n := &Node[K, V]{Key: keys[middle], Value: values[middle], parent: parent}
+	n.Previous = newBalancedNode(keys[:middle], values[:middle], n)
+	n.Next = newBalancedNode(keys[middle+1:], values[middle+1:], n)
+	n.updateDepth()
+	return n
+}
+
+// Get() search in the node the value of the key and returns it if present (a NaN key is never present)
 func (n *Node[K, V]) Get(key K) *Node[K, V] {
+	if isNaN(key) {
+		return nil
+	}
 
 	switch {
 	case key > n.Key: //key is bigger than the n.Key
@@ -259,10 +435,21 @@ func (n *Node[K, V]) Get(key K) *Node[K, V] {
 
 // Delete() will delete the node if the key is found and returns the new RootNode
 func (n *Node[K, V]) Delete() *Node[K, V] {
+	return n.delete(nil)
+}
+
+// DeleteTraced() acts like Delete(), reporting its steps to tracer (nil for none), like a Tree with a tracer (see Tree.SetTracer())
+func (n *Node[K, V]) DeleteTraced(tracer Tracer[K, V]) *Node[K, V] {
+	return n.delete(probeOf(tracer))
+}
+
+// delete() acts like Delete(), counting and tracing the changes with p (if not nil)
+func (n *Node[K, V]) delete(p *probe[K, V]) *Node[K, V] {
 
 	switch {
 	case n.Next == nil && n.Previous == nil: //The node to delete is a leaf... Simply delete it !
 		if n.parent == nil { //the node to delete is the only node (and the root node...) simply return nil informing the tree that there's no more node
+			p.trace(TraceRemove, n.Key, nil)
 			return nil
 		}
 		//other case : delete the parent link to this node (previous or next)
@@ -271,18 +458,21 @@ func (n *Node[K, V]) Delete() *Node[K, V] {
 		} else {
 			n.parent.Next = nil
 		}
-		return n.parent.balance()
+		p.trace(TraceRemove, n.Key, n.parent)
+		return n.parent.balance(p)
 	case (n.Next == nil && n.Previous != nil) || (n.Next != nil && n.Previous == nil): //The node has only one child
 		if n.parent == nil { //the node to delete is the rootnode, so simply return its only child has new root node
 			if n.Previous != nil {
 				n.Previous.parent = nil
 				newRoot := n.Previous
 				n.Previous = nil
+				p.trace(TraceRemove, n.Key, newRoot)
 				return newRoot
 			} else {
 				n.Next.parent = nil
 				newRoot := n.Next
 				n.Next = nil
+				p.trace(TraceRemove, n.Key, newRoot)
 				return newRoot
 			}
 		} else {
@@ -303,7 +493,8 @@ func (n *Node[K, V]) Delete() *Node[K, V] {
 			n.Next = nil
 			n.Previous = nil
 
-			return successor.parent.balance()
+			p.trace(TraceRemove, n.Key, successor)
+			return successor.parent.balance(p)
 		}
 	default: //the node to delete has two children
 		//find the successor (min value of its next subtree)
@@ -322,13 +513,14 @@ func (n *Node[K, V]) Delete() *Node[K, V] {
 			successor.Previous = n.Previous
 			successor.Previous.parent = successor
 			n.parent, n.Next, n.Previous = nil, nil, nil
-			return successor.balance()
+			p.trace(TraceSuccessorSwap, n.Key, successor)
+			p.trace(TraceRemove, n.Key, successor)
+			return successor.balance(p)
 		} else {
 			//swap n and its successor
-			successor.Previous = n.Previous
-			successor.Previous.parent = successor
-			n.Previous = nil
-			successorparent := successor.parent
+			successorParent, successorNext := successor.parent, successor.Next
+
+			//the successor takes the place of n...
 			successor.parent = n.parent
 			if successor.parent != nil {
 				if successor.parent.Previous == n {
@@ -337,22 +529,72 @@ func (n *Node[K, V]) Delete() *Node[K, V] {
 					successor.parent.Next = successor
 				}
 			}
-			n.parent = successorparent
-			successorparent.Previous = n
-			successorNext := successor.Next
+			successor.Previous = n.Previous
+			successor.Previous.parent = successor
 			successor.Next = n.Next
-			successor.Next.parent = successorNext
+			successor.Next.parent = successor
+
+			//...and n takes the place of the successor (which was the Previous of its parent)
+			n.parent = successorParent
+			successorParent.Previous = n
+			n.Previous = nil
+			n.Next = successorNext
 			if successorNext != nil {
 				successorNext.parent = n
-				n.Next = successorNext
 			}
 			//n and its successor are now swapped.
 			//The tree is always balanded !
 			//Just delete n (it will have only one or no child)
-			return n.Delete()
+			p.trace(TraceSuccessorSwap, n.Key, n)
+			return n.delete(p)
+		}
+	}
+
+}
+
+// isValid() checks that the subtree of the node is a valid AVL tree :
+// keys are strictly ordered (and not NaN), parent links and cached depths are consistent and every node is balanced.
+// It returns the depth of the subtree and false if something is wrong
+func (n *Node[K, V]) isValid() (depth int, ok bool) {
+	if isNaN(n.Key) { //a NaN key can't be ordered
+		return 0, false
+	}
+	previousDepth, nextDepth := 0, 0
+	if n.Previous != nil {
+		if n.Previous.parent != n || n.Previous.max().Key >= n.Key {
+			return 0, false
+		}
+		if previousDepth, ok = n.Previous.isValid(); !ok {
+			return 0, false
+		}
+	}
+	if n.Next != nil {
+		if n.Next.parent != n || n.Next.min().Key <= n.Key {
+			return 0, false
 		}
+		if nextDepth, ok = n.Next.isValid(); !ok {
+			return 0, false
+		}
+	}
+	if balance := nextDepth - previousDepth; balance < -1 || balance > 1 {
+		return 0, false
+	}
+	depth = 1 + nextDepth
+	if previousDepth > nextDepth {
+		depth = 1 + previousDepth
 	}
+	if n.depth != 0 && n.depth != depth { //the cached depth is wrong
+		return 0, false
+	}
+	return depth, true
+}
 
+// max() is used to find the max key of a node's subtree
+func (n *Node[K, V]) max() *Node[K, V] {
+	if n.Next == nil {
+		return n
+	}
+	return n.Next.max()
 }
 
 // min() is used to find the min key of a node's subtree
@@ -362,3 +604,152 @@ func (n *Node[K, V]) min() *Node[K, V] {
 	}
 	return n.Previous.min()
 }
+
+// successor() returns the node with the next key of the tree (nil for the max key), following the parent links
+func (n *Node[K, V]) successor() *Node[K, V] {
+	if n.Next != nil {
+		return n.Next.min()
+	}
+	for n.parent != nil && n.parent.Next == n {
+		n = n.parent
+	}
+	return n.parent
+}
+
+// predecessor() returns the node with the previous key of the tree (nil for the min key), following the parent links
+func (n *Node[K, V]) predecessor() *Node[K, V] {
+	if n.Previous != nil {
+		return n.Previous.max()
+	}
+	for n.parent != nil && n.parent.Previous == n {
+		n = n.parent
+	}
+	return n.parent
+}
+
+// detach() unlinks the node from its parent and its children and returns its children, which become roots
+func (n *Node[K, V]) detach() (previous, next *Node[K, V]) {
+	previous, next = n.Previous, n.Next
+	if previous != nil {
+		previous.parent = nil
+	}
+	if next != nil {
+		next.parent = nil
+	}
+	n.parent, n.Previous, n.Next = nil, nil, nil
+	return previous, next
+}
+
+// split() splits the subtree of the root node n in two balanced trees : the keys for which isLeft() is true,
+// and the others. isLeft() must be true for the smallest keys only (like "key < k").
+// It returns the root nodes of both trees and runs in O(log n). The rebalancing is counted and traced by p (if not nil)
+func (n *Node[K, V]) split(isLeft func(key K) bool, p *probe[K, V]) (left, right *Node[K, V]) {
+	if n == nil {
+		return nil, nil
+	}
+	previous, next := n.detach()
+	if isLeft(n.Key) {
+		l, r := next.split(isLeft, p)
+		return join(previous, n, l, p), r
+	}
+	l, r := previous.split(isLeft, p)
+	return l, join(r, n, next, p)
+}
+
+// join() returns the root node of a balanced tree made of the keys of left, the detached node middle, and the keys of right.
+// Every key of left must be smaller than middle.Key, and every key of right bigger.
+// It runs in O(|depth of left - depth of right|)
+func join[K Ordered, V any](left, middle, right *Node[K, V], p *probe[K, V]) *Node[K, V] {
+	leftDepth, rightDepth := left.getDepth(), right.getDepth()
+	switch {
+	case leftDepth > rightDepth+1: //hang middle on the Next spine of left, where the depth matches right
+		spine := left
+		for spine.Next.getDepth() > rightDepth+1 {
+			spine = spine.Next
+		}
+		middle.Previous, middle.Next = spine.Next, right
+		spine.Next = middle
+		middle.parent = spine
+	case rightDepth > leftDepth+1: //hang middle on the Previous spine of right, where the depth matches left
+		spine := right
+		for spine.Previous.getDepth() > leftDepth+1 {
+			spine = spine.Previous
+		}
+		middle.Previous, middle.Next = left, spine.Previous
+		spine.Previous = middle
+		middle.parent = spine
+	default:
+		middle.Previous, middle.Next = left, right
+	}
+	if middle.Previous != nil {
+		middle.Previous.parent = middle
+	}
+	if middle.Next != nil {
+		middle.Next.parent = middle
+	}
+	middle.updateDepth()
+	if middle.parent == nil {
+		return middle
+	}
+	return middle.parent.rebalance(p)
+}
+
+// join2() returns the root node of a balanced tree made of the keys of left and right.
+// Every key of left must be smaller than the keys of right
+func join2[K Ordered, V any](left, right *Node[K, V], p *probe[K, V]) *Node[K, V] {
+	if left == nil {
+		return right
+	}
+	if right == nil {
+		return left
+	}
+	//the biggest key of left joins both trees
+	middle := left.max()
+	left = middle.delete(p)
+	middle.detach()
+	return join(left, middle, right, p)
+}
+
+// rebalance() restores the balance of the nodes from n up to the root, like balance(),
+// but stops climbing as soon as the depth of a subtree doesn't change. It returns the root node
+func (n *Node[K, V]) rebalance(p *probe[K, V]) *Node[K, V] {
+	root := n
+	for root.parent != nil {
+		root = root.parent
+	}
+	for n != nil {
+		depth := n.depth
+		n.updateDepth()
+		if balance := n.getBalance(); balance > 1 || balance < -1 {
+			n.rotate(balance, p)
+			n = n.parent
+		}
+		if n.parent == nil {
+			return n
+		}
+		if n.depth == depth {
+			break
+		}
+		n = n.parent
+	}
+	//a rotation may have moved the root under a new node
+	for root.parent != nil {
+		root = root.parent
+	}
+	return root
+}
+
+// relink() links the ordered nodes in a balanced tree attached to parent and returns its root node (nil if there is no node)
+// Unlike newBalancedNode(), it reuses the nodes. It runs in O(n) without any rotation
+func relink[K Ordered, V any](nodes []*Node[K, V], parent *Node[K, V]) *Node[K, V] {
+	if len(nodes) == 0 {
+		return nil
+	}
+	middle := len(nodes) / 2
+	n := nodes[middle]
+	n.parent = parent
+	n.Previous = relink(nodes[:middle], n)
+	n.Next = relink(nodes[middle+1:], n)
+	n.updateDepth()
+	return n
+}
@@ -0,0 +1,59 @@
+package avlgo
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// Entry is a key/value of a Tree
+type Entry[K Ordered, V any] struct {
+	Key   K
+	Value V
+}
+
+// GetFromToPage() returns, in order, at most limit entries for keys found between from and to (including bounds or not),
+// starting after the key of the cursor (an empty cursor starts at from)
+// next is the cursor of the following page, or an empty string if there is no more entry
+// A cursor only holds the last returned key : it stays valid whatever is put or deleted in the tree
+func (t *Tree[K, V]) GetFromToPage(from, to K, boundsIncluded bool, limit int, cursor string) (entries []Entry[K, V], next string, err error) {
+	if from > to { //GetFromTo() returns nothing for such a range
+		return nil, "", nil
+	}
+	lo, hi := boundsOf(from, to, boundsIncluded)
+	return t.GetRangePage(lo, hi, limit, cursor)
+}
+
+// GetFromToPage() returns, in order, at most limit entries for keys found between from and to, like Tree.GetFromToPage()
+func (t *UnsyncTree[K, V]) GetFromToPage(from, to K, boundsIncluded bool, limit int, cursor string) (entries []Entry[K, V], next string, err error) {
+	if from > to { //GetFromTo() returns nothing for such a range
+		return nil, "", nil
+	}
+	lo, hi := boundsOf(from, to, boundsIncluded)
+	return t.GetRangePage(lo, hi, limit, cursor)
+}
+
+// GetFromToPage() returns, in order, at most limit entries for keys found between from and to, like Tree.GetFromToPage()
+func (st *ShardedTree[K, V]) GetFromToPage(from, to K, boundsIncluded bool, limit int, cursor string) (entries []Entry[K, V], next string, err error) {
+	if from > to { //GetFromTo() returns nothing for such a range
+		return nil, "", nil
+	}
+	lo, hi := boundsOf(from, to, boundsIncluded)
+	return st.GetRangePage(lo, hi, limit, cursor)
+}
+
+// encodeCursor() returns the opaque cursor of the key (its tuple encoding in base64)
+func encodeCursor[K Ordered](key K) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(encodeTuple(key)))
+}
+
+// decodeCursor() returns the key of a cursor built by encodeCursor()
+func decodeCursor[K Ordered](cursor string) (key K, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return key, fmt.Errorf("unable to decode cursor : %s", err)
+	}
+	if err = decodeTuple(TupleKey(b), &key); err != nil {
+		return key, fmt.Errorf("unable to decode cursor : %s", err)
+	}
+	return key, nil
+}
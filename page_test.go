@@ -0,0 +1,81 @@
+package avlgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetFromToPage(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantTree[int, int](v)
+		for i := 0; i < 100; i++ {
+			tree.PutOne(i, i*10)
+		}
+
+		keys := make([]int, 0)
+		cursor, pages := "", 0
+		for {
+			entries, next, err := tree.GetFromToPage(10, 50, true, 7, cursor)
+			if err != nil {
+				t.Fatalf("GetFromToPage shouldn't return an error. %s is returned", err)
+			}
+			for _, e := range entries {
+				if e.Value != e.Key*10 {
+					t.Errorf("value of %d is %d, want %d", e.Key, e.Value, e.Key*10)
+				}
+				keys = append(keys, e.Key)
+			}
+			pages++
+
+			//changes between two pages : the cursor resumes after the last returned key
+			if pages == 2 {
+				tree.Delete(keys[len(keys)-1], keys[len(keys)-1]+1)
+				tree.PutOne(-1, 0)
+				tree.PutOne(1000, 0)
+			}
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+
+		wanted := make([]int, 0)
+		for i := 10; i <= 50; i++ {
+			if i != 24 {
+				wanted = append(wanted, i)
+			}
+		}
+		if !reflect.DeepEqual(keys, wanted) {
+			t.Errorf("keys are %v, want %v", keys, wanted)
+		}
+		if pages != 6 {
+			t.Errorf("pages are %d, want 6", pages)
+		}
+
+		//a full last page doesn't need another page
+		entries, next, _ := tree.GetFromToPage(0, 4, false, 3, "")
+		if len(entries) != 3 || next != "" {
+			t.Errorf("GetFromToPage returns %d entries and %q, want 3 entries and no cursor", len(entries), next)
+		}
+	})
+}
+
+func TestGetFromToPageErrors(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantTree[string, int](v)
+		tree.PutOne("a", 1)
+		if _, _, err := tree.GetFromToPage("a", "z", true, 0, ""); err == nil {
+			t.Errorf("GetFromToPage should reject a zero limit")
+		}
+		if _, _, err := tree.GetFromToPage("a", "z", true, 10, "%%%"); err == nil {
+			t.Errorf("GetFromToPage should reject an invalid cursor")
+		}
+		if _, _, err := tree.GetFromToPage("a", "z", true, 10, encodeCursor(42)); err == nil {
+			t.Errorf("GetFromToPage should reject a cursor of another key type")
+		}
+		entries, _, err := tree.GetFromToPage("b", "z", true, 10, encodeCursor("0"))
+		if err != nil || len(entries) != 0 {
+			t.Errorf("a cursor before the range should start at from")
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package avlgo
+
+// WithPrefix() calls fn for each key of the tree starting with prefix, in order, until fn returns false
+// Keys are compared byte per byte, so the prefix may end in the middle of a UTF-8 character
+// The walk is bounded by the successor of the prefix : it doesn't visit the other keys
+func WithPrefix[K ~string, V any](t AnyTree[K, V], prefix K, fn func(key K, value V) bool) {
+	t.read(func(t *core[K, V]) {
+		t.ascend(Inclusive(prefix), prefixUpperBound(prefix), fn)
+	})
+}
+
+// CountPrefix() returns the number of keys of the tree starting with prefix
+func CountPrefix[K ~string, V any](t AnyTree[K, V], prefix K) (count int) {
+	WithPrefix(t, prefix, func(key K, value V) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// DeletePrefix() removes the keys of the tree starting with prefix and returns the number of removed keys
+func DeletePrefix[K ~string, V any](t AnyTree[K, V], prefix K) int {
+	deleted, _ := t.DeleteRange(Inclusive(prefix), prefixUpperBound(prefix))
+	return deleted
+}
+
+// prefixUpperBound() returns the upper bound of the keys starting with prefix
+func prefixUpperBound[K ~string](prefix K) Bound[K] {
+	if successor, bounded := prefixSuccessor(prefix); bounded {
+		return Exclusive(successor)
+	}
+	return Unbounded[K]()
+}
+
+// prefixSuccessor() returns the smallest string bigger than every string starting with prefix
+// bounded is false if there is none (the prefix is empty or only made of 0xff bytes) : every key after
+// the prefix starts with it
+func prefixSuccessor[K ~string](prefix K) (successor K, bounded bool) {
+	b := []byte(prefix)
+	//"ab\xff" and "ab\xff\xff..." are followed by "ac", so drop the trailing 0xff bytes and increment the last one
+	for len(b) > 0 && b[len(b)-1] == 0xff {
+		b = b[:len(b)-1]
+	}
+	if len(b) == 0 {
+		return successor, false
+	}
+	b[len(b)-1]++
+	return K(b), true
+}
@@ -0,0 +1,76 @@
+package avlgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPrefixSuccessor(t *testing.T) {
+	tests := []struct {
+		prefix, successor string
+		bounded           bool
+	}{
+		{"abc", "abd", true},
+		{"/users/", "/users0", true},
+		{"a\xff", "b", true},
+		{"a\xff\xff", "b", true},
+		{"\xff\xff", "", false},
+		{"", "", false},
+		{"é", "ê", true},
+	}
+	for _, test := range tests {
+		successor, bounded := prefixSuccessor(test.prefix)
+		if successor != test.successor || bounded != test.bounded {
+			t.Errorf("prefixSuccessor(%q) returns %q, %v, want %q, %v", test.prefix, successor, bounded, test.successor, test.bounded)
+		}
+	}
+}
+
+func TestPrefixQueries(t *testing.T) {
+	type path string
+	tree := NewTree[path, int]()
+	keys := []path{"/users", "/users/1", "/users/1/name", "/users/12", "/users/2", "/users0", "/usersX", "/groups/1", "a\xff", "a\xff\xff", "a\xffb", "b", "\xff", "\xff\xff"}
+	for i, k := range keys {
+		tree.PutOne(k, i)
+	}
+
+	found := make([]path, 0)
+	WithPrefix[path, int](tree, "/users/1", func(key path, value int) bool {
+		found = append(found, key)
+		return true
+	})
+	wanted := []path{"/users/1", "/users/1/name", "/users/12"}
+	if !reflect.DeepEqual(found, wanted) {
+		t.Errorf("keys are %v, want %v", found, wanted)
+	}
+
+	tests := []struct {
+		prefix path
+		count  int
+	}{
+		{"/users", 7},
+		{"/users/", 4},
+		{"/nothing", 0},
+		{"a\xff", 3},
+		{"\xff", 2},
+		{"", len(keys)},
+	}
+	for _, test := range tests {
+		if count := CountPrefix[path, int](tree, test.prefix); count != test.count {
+			t.Errorf("CountPrefix(%q) returns %d, want %d", test.prefix, count, test.count)
+		}
+	}
+
+	if deleted := DeletePrefix[path, int](tree, "/users/"); deleted != 4 {
+		t.Errorf("DeletePrefix removes %d keys, want 4", deleted)
+	}
+	if tree.Size() != len(keys)-4 {
+		t.Errorf("Tree size is %d, want %d", tree.Size(), len(keys)-4)
+	}
+	if deleted := DeletePrefix[path, int](tree, "\xff"); deleted != 2 {
+		t.Errorf("DeletePrefix removes %d keys, want 2", deleted)
+	}
+	if count := CountPrefix[path, int](tree, "/users"); count != 3 {
+		t.Errorf("CountPrefix returns %d, want 3", count)
+	}
+}
@@ -0,0 +1,312 @@
+package avlgo
+
+import "sync"
+
+// RBTree is a left-leaning red-black tree implementing SortedMap
+// It is less strictly balanced than an AVL tree (up to 2*log2(n) deep instead of 1.44*log2(n)),
+// so its reads descend a bit deeper, but its puts and deletes rotate less
+// Like a Tree, it is safe for concurrent use : reads take a read lock, writes a lock
+type RBTree[K Ordered, V any] struct {
+	rwMutex sync.RWMutex
+	root    *rbNode[K, V]
+	size    int
+}
+
+// rbNode is a node of a RBTree. red is the color of the link from its parent
+type rbNode[K Ordered, V any] struct {
+	key            K
+	value          V
+	previous, next *rbNode[K, V]
+	red            bool
+}
+
+// NewRBTree() returns an empty new RBTree
+func NewRBTree[K Ordered, V any]() *RBTree[K, V] {
+	return &RBTree[K, V]{}
+}
+
+// Size() returns the number of entries of the tree
+func (t *RBTree[K, V]) Size() int {
+	t.rwMutex.RLock()
+	defer t.rwMutex.RUnlock()
+	return t.size
+}
+
+// Get() returns the value present in the tree for the key
+func (t *RBTree[K, V]) Get(key K) (value V, ok bool) {
+	t.rwMutex.RLock()
+	defer t.rwMutex.RUnlock()
+	if n := t.root.get(key); n != nil {
+		return n.value, true
+	}
+	return value, false
+}
+
+// GetFromTo() returns an ordered slice of values for keys found between from and to (including bounds or not)
+func (t *RBTree[K, V]) GetFromTo(from, to K, boundsIncluded bool) (values []V) {
+	t.AscendFromTo(from, to, boundsIncluded, func(key K, value V) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+// Ascend() calls fn for each key/value of the tree, in order, until fn returns false
+// The tree is read-locked during the walk, so fn must not modify it
+func (t *RBTree[K, V]) Ascend(fn func(key K, value V) bool) {
+	t.rwMutex.RLock()
+	defer t.rwMutex.RUnlock()
+	t.root.ascend(Unbounded[K](), Unbounded[K](), fn)
+}
+
+// AscendFromTo() acts like Ascend() for the keys between from and to (including bounds or not)
+func (t *RBTree[K, V]) AscendFromTo(from, to K, boundsIncluded bool, fn func(key K, value V) bool) {
+	t.rwMutex.RLock()
+	defer t.rwMutex.RUnlock()
+	lo, hi := boundsOf(from, to, boundsIncluded)
+	t.root.ascend(lo, hi, fn)
+}
+
+// PutOne() adds one element in the tree, replacing the value if the key is already present
+// A NaN key is not put : it returns false
+func (t *RBTree[K, V]) PutOne(key K, value V) bool {
+	if isNaN(key) {
+		return false
+	}
+	t.rwMutex.Lock()
+	defer t.rwMutex.Unlock()
+
+	var added bool
+	t.root, added = t.root.put(key, value)
+	t.root.red = false
+	if added {
+		t.size++
+	}
+	return true
+}
+
+// Delete() removes the entries of the passed keys and returns the number of entries deleted
+func (t *RBTree[K, V]) Delete(keys ...K) int {
+	t.rwMutex.Lock()
+	defer t.rwMutex.Unlock()
+
+	deleted := 0
+	for _, k := range keys {
+		//the descent recolors the nodes on its way : it must only be done for a present key
+		if t.root.get(k) == nil {
+			continue
+		}
+		if !t.root.previous.isRed() && !t.root.next.isRed() {
+			t.root.red = true
+		}
+		t.root = t.root.delete(k)
+		if t.root != nil {
+			t.root.red = false
+		}
+		t.size--
+		deleted++
+	}
+	return deleted
+}
+
+// isValid() checks the order of the keys, that red links lean left and never follow each other,
+// and that every path from the root holds the same number of black links
+func (t *RBTree[K, V]) isValid() bool {
+	t.rwMutex.RLock()
+	defer t.rwMutex.RUnlock()
+	_, ok := t.root.check(Unbounded[K](), Unbounded[K]())
+	return ok && !t.root.isRed()
+}
+
+// isRed() returns true for a node linked in red (false for nil)
+func (n *rbNode[K, V]) isRed() bool {
+	return n != nil && n.red
+}
+
+// get() returns the node of the key in the subtree (nil if absent, or NaN)
+func (n *rbNode[K, V]) get(key K) *rbNode[K, V] {
+	if isNaN(key) {
+		return nil
+	}
+	for n != nil {
+		switch {
+		case key < n.key:
+			n = n.previous
+		case key > n.key:
+			n = n.next
+		default:
+			return n
+		}
+	}
+	return nil
+}
+
+// ascend() calls fn for the key/values of the subtree between the bounds, in order,
+// and returns false if fn stopped the walk
+func (n *rbNode[K, V]) ascend(lo, hi Bound[K], fn func(key K, value V) bool) bool {
+	if n == nil {
+		return true
+	}
+	if lo.kind == boundUnbounded || n.key > lo.key {
+		if !n.previous.ascend(lo, hi, fn) {
+			return false
+		}
+	}
+	if lo.admitsAbove(n.key) && hi.admitsBelow(n.key) {
+		if !fn(n.key, n.value) {
+			return false
+		}
+	}
+	if hi.kind == boundUnbounded || n.key < hi.key {
+		return n.next.ascend(lo, hi, fn)
+	}
+	return true
+}
+
+// put() puts the key/value in the subtree and returns its new root, and true if the key was added
+func (n *rbNode[K, V]) put(key K, value V) (*rbNode[K, V], bool) {
+	if n == nil {
+		return &rbNode[K, V]{key: key, value: value, red: true}, true
+	}
+	var added bool
+	switch {
+	case key < n.key:
+		n.previous, added = n.previous.put(key, value)
+	case key > n.key:
+		n.next, added = n.next.put(key, value)
+	default:
+		n.value = value
+	}
+	return n.fixUp(), added
+}
+
+// delete() removes the key, which must be present, from the subtree and returns its new root
+// On the way down, a red link is pushed in front of the descent so that the removed node is never a lone black one
+func (n *rbNode[K, V]) delete(key K) *rbNode[K, V] {
+	if key < n.key {
+		if !n.previous.isRed() && !n.previous.previous.isRed() {
+			n = n.moveRedLeft()
+		}
+		n.previous = n.previous.delete(key)
+		return n.fixUp()
+	}
+
+	if n.previous.isRed() {
+		n = n.rotateRight()
+	}
+	if key == n.key && n.next == nil {
+		return nil
+	}
+	if !n.next.isRed() && !n.next.previous.isRed() {
+		n = n.moveRedRight()
+	}
+	if key == n.key {
+		//replace the node by its successor, removed from the next subtree
+		successor := n.next
+		for successor.previous != nil {
+			successor = successor.previous
+		}
+		n.key, n.value = successor.key, successor.value
+		n.next = n.next.deleteMin()
+	} else {
+		n.next = n.next.delete(key)
+	}
+	return n.fixUp()
+}
+
+// deleteMin() removes the smallest key of the subtree and returns its new root
+func (n *rbNode[K, V]) deleteMin() *rbNode[K, V] {
+	if n.previous == nil {
+		return nil
+	}
+	if !n.previous.isRed() && !n.previous.previous.isRed() {
+		n = n.moveRedLeft()
+	}
+	n.previous = n.previous.deleteMin()
+	return n.fixUp()
+}
+
+// rotateLeft() and rotateRight() turn a red link to the other side and return the new root of the subtree
+func (n *rbNode[K, V]) rotateLeft() *rbNode[K, V] {
+	x := n.next
+	n.next = x.previous
+	x.previous = n
+	x.red, n.red = n.red, true
+	return x
+}
+
+func (n *rbNode[K, V]) rotateRight() *rbNode[K, V] {
+	x := n.previous
+	n.previous = x.next
+	x.next = n
+	x.red, n.red = n.red, true
+	return x
+}
+
+// flipColors() flips the colors of the node and its two children
+func (n *rbNode[K, V]) flipColors() {
+	n.red = !n.red
+	n.previous.red = !n.previous.red
+	n.next.red = !n.next.red
+}
+
+// moveRedLeft() makes the previous child, or one of its children, red before descending to the left
+func (n *rbNode[K, V]) moveRedLeft() *rbNode[K, V] {
+	n.flipColors()
+	if n.next.previous.isRed() {
+		n.next = n.next.rotateRight()
+		n = n.rotateLeft()
+		n.flipColors()
+	}
+	return n
+}
+
+// moveRedRight() makes the next child, or one of its children, red before descending to the right
+func (n *rbNode[K, V]) moveRedRight() *rbNode[K, V] {
+	n.flipColors()
+	if n.previous.previous.isRed() {
+		n = n.rotateRight()
+		n.flipColors()
+	}
+	return n
+}
+
+// fixUp() restores the invariants of the node on the way up and returns the new root of the subtree :
+// red links lean left, never follow each other, and a node with two red links splits
+func (n *rbNode[K, V]) fixUp() *rbNode[K, V] {
+	if n.next.isRed() && !n.previous.isRed() {
+		n = n.rotateLeft()
+	}
+	if n.previous.isRed() && n.previous.previous.isRed() {
+		n = n.rotateRight()
+	}
+	if n.previous.isRed() && n.next.isRed() {
+		n.flipColors()
+	}
+	return n
+}
+
+// check() checks the subtree, whose keys must be between the bounds, and returns its number of black links
+func (n *rbNode[K, V]) check(lo, hi Bound[K]) (blackLinks int, ok bool) {
+	if n == nil {
+		return 0, true
+	}
+	if !lo.admitsAbove(n.key) || !hi.admitsBelow(n.key) {
+		return 0, false
+	}
+	if n.next.isRed() || (n.red && n.previous.isRed()) {
+		return 0, false
+	}
+	previous, ok := n.previous.check(lo, Exclusive(n.key))
+	if !ok {
+		return 0, false
+	}
+	next, ok := n.next.check(Exclusive(n.key), hi)
+	if !ok || previous != next {
+		return 0, false
+	}
+	if !n.red {
+		previous++
+	}
+	return previous, true
+}
@@ -0,0 +1,279 @@
+package avlgo
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// RCUTree is an AVL tree whose readers never lock
+// Its nodes are immutable : a writer copies the path from the root to the changed node,
+// then publishes the new root atomically (read-copy-update). Readers load the published root
+// and walk a version of the tree which can't change under them
+// Writers are serialized by a mutex, and a write allocates O(log n) nodes
+type RCUTree[K Ordered, V any] struct {
+	root       atomic.Pointer[rcuRoot[K, V]] //the published version of the tree
+	writeMutex sync.Mutex                    //serializes the writers
+}
+
+// rcuRoot is a published version of a RCUTree
+type rcuRoot[K Ordered, V any] struct {
+	node *rcuNode[K, V]
+	size int
+}
+
+// rcuNode is an immutable node of a RCUTree
+type rcuNode[K Ordered, V any] struct {
+	key            K
+	value          V
+	previous, next *rcuNode[K, V]
+	depth          int
+}
+
+// NewRCUTree() returns an empty new RCUTree
+func NewRCUTree[K Ordered, V any]() *RCUTree[K, V] {
+	t := &RCUTree[K, V]{}
+	t.root.Store(&rcuRoot[K, V]{})
+	return t
+}
+
+// Size() returns the number of entries of the tree
+func (t *RCUTree[K, V]) Size() int {
+	return t.root.Load().size
+}
+
+// Depth() returns the depth of the tree
+func (t *RCUTree[K, V]) Depth() int {
+	return t.root.Load().node.getDepth()
+}
+
+// Get() returns the value present in the tree for the key, without locking
+func (t *RCUTree[K, V]) Get(key K) (value V, ok bool) {
+	if isNaN(key) {
+		return value, false
+	}
+	n := t.root.Load().node
+	for n != nil {
+		switch {
+		case key > n.key:
+			n = n.next
+		case key < n.key:
+			n = n.previous
+		default:
+			return n.value, true
+		}
+	}
+	return value, false
+}
+
+// GetFromTo() returns an ordered slice of values for keys found between from and to (including bounds or not), without locking
+// The values all come from the same version of the tree
+func (t *RCUTree[K, V]) GetFromTo(from, to K, boundsIncluded bool) (values []V) {
+	t.AscendFromTo(from, to, boundsIncluded, func(key K, value V) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+// Ascend() calls fn for each key/value of a version of the tree, in order, until fn returns false
+// Nothing is locked : fn may modify the tree, the changes are not seen by the walk
+func (t *RCUTree[K, V]) Ascend(fn func(key K, value V) bool) {
+	t.root.Load().node.ascend(Unbounded[K](), Unbounded[K](), fn)
+}
+
+// AscendFromTo() acts like Ascend() for the keys between from and to (including bounds or not)
+func (t *RCUTree[K, V]) AscendFromTo(from, to K, boundsIncluded bool, fn func(key K, value V) bool) {
+	lo, hi := boundsOf(from, to, boundsIncluded)
+	t.root.Load().node.ascend(lo, hi, fn)
+}
+
+// PutOne() adds one element in the tree, replacing the value if the key is already present
+// A NaN key is not put : it returns false
+func (t *RCUTree[K, V]) PutOne(key K, value V) bool {
+	if isNaN(key) {
+		return false
+	}
+	t.writeMutex.Lock()
+	defer t.writeMutex.Unlock()
+
+	root := t.root.Load()
+	node, added := root.node.put(key, value)
+	size := root.size
+	if added {
+		size++
+	}
+	t.root.Store(&rcuRoot[K, V]{node: node, size: size})
+	return true
+}
+
+// Delete() removes the nodes corresponding to the passed keys and returns the number of nodes deleted
+// The keys are removed at once : readers see all of them or none of them
+func (t *RCUTree[K, V]) Delete(keys ...K) int {
+	t.writeMutex.Lock()
+	defer t.writeMutex.Unlock()
+
+	root := t.root.Load()
+	node, deleted := root.node, 0
+	for _, k := range keys {
+		if isNaN(k) {
+			continue
+		}
+		var removed bool
+		if node, removed = node.delete(k); removed {
+			deleted++
+		}
+	}
+	if deleted > 0 {
+		t.root.Store(&rcuRoot[K, V]{node: node, size: root.size - deleted})
+	}
+	return deleted
+}
+
+// Compute() atomically reads, modifies and writes the value of the key, like Tree.Compute()
+// Readers are never blocked, but fn must not write in the tree
+func (t *RCUTree[K, V]) Compute(key K, fn func(old V, ok bool) (V, Op)) (value V, ok bool) {
+	if isNaN(key) {
+		return value, false
+	}
+	t.writeMutex.Lock()
+	defer t.writeMutex.Unlock()
+
+	root := t.root.Load()
+	old, found := t.Get(key)
+	value, op := fn(old, found)
+	switch op {
+	case OpPut:
+		node, added := root.node.put(key, value)
+		size := root.size
+		if added {
+			size++
+		}
+		t.root.Store(&rcuRoot[K, V]{node: node, size: size})
+		return value, true
+	case OpDelete:
+		if node, removed := root.node.delete(key); removed {
+			t.root.Store(&rcuRoot[K, V]{node: node, size: root.size - 1})
+		}
+		var zero V
+		return zero, false
+	default:
+		return old, found
+	}
+}
+
+// getDepth() returns the depth of the node (0 for a nil node)
+func (n *rcuNode[K, V]) getDepth() int {
+	if n == nil {
+		return 0
+	}
+	return n.depth
+}
+
+// newRCUNode() returns a new node with its depth computed from its children
+func newRCUNode[K Ordered, V any](key K, value V, previous, next *rcuNode[K, V]) *rcuNode[K, V] {
+	depth := previous.getDepth()
+	if next.getDepth() > depth {
+		depth = next.getDepth()
+	}
+	return &rcuNode[K, V]{key: key, value: value, previous: previous, next: next, depth: depth + 1}
+}
+
+// balancedRCUNode() returns a new node for key/value with the previous and next children,
+// performing one (or two) rotation if the depths of the children differ by 2
+func balancedRCUNode[K Ordered, V any](key K, value V, previous, next *rcuNode[K, V]) *rcuNode[K, V] {
+	balance := next.getDepth() - previous.getDepth()
+	switch {
+	case balance > 1: //unbalanced node with deeper next
+		if next.previous.getDepth() > next.next.getDepth() { //double rotation
+			p := next.previous
+			return newRCUNode(p.key, p.value,
+				newRCUNode(key, value, previous, p.previous),
+				newRCUNode(next.key, next.value, p.next, next.next))
+		}
+		return newRCUNode(next.key, next.value, newRCUNode(key, value, previous, next.previous), next.next)
+	case balance < -1: //unbalanced node with deeper previous
+		if previous.next.getDepth() > previous.previous.getDepth() { //double rotation
+			n := previous.next
+			return newRCUNode(n.key, n.value,
+				newRCUNode(previous.key, previous.value, previous.previous, n.previous),
+				newRCUNode(key, value, n.next, next))
+		}
+		return newRCUNode(previous.key, previous.value, previous.previous, newRCUNode(key, value, previous.next, next))
+	default:
+		return newRCUNode(key, value, previous, next)
+	}
+}
+
+// put() returns a copy of the subtree with the key/value, and true if the key was added
+func (n *rcuNode[K, V]) put(key K, value V) (*rcuNode[K, V], bool) {
+	if n == nil {
+		return &rcuNode[K, V]{key: key, value: value, depth: 1}, true
+	}
+	switch {
+	case key > n.key:
+		next, added := n.next.put(key, value)
+		return balancedRCUNode(n.key, n.value, n.previous, next), added
+	case key < n.key:
+		previous, added := n.previous.put(key, value)
+		return balancedRCUNode(n.key, n.value, previous, n.next), added
+	default: //the key is kept, like in a Tree (-0 stays -0 when +0 is put)
+		return &rcuNode[K, V]{key: n.key, value: value, previous: n.previous, next: n.next, depth: n.depth}, false
+	}
+}
+
+// delete() returns a copy of the subtree without the key, and true if the key was removed
+func (n *rcuNode[K, V]) delete(key K) (*rcuNode[K, V], bool) {
+	if n == nil {
+		return nil, false
+	}
+	switch {
+	case key > n.key:
+		next, removed := n.next.delete(key)
+		if !removed {
+			return n, false
+		}
+		return balancedRCUNode(n.key, n.value, n.previous, next), true
+	case key < n.key:
+		previous, removed := n.previous.delete(key)
+		if !removed {
+			return n, false
+		}
+		return balancedRCUNode(n.key, n.value, previous, n.next), true
+	default:
+		if n.previous == nil {
+			return n.next, true
+		}
+		if n.next == nil {
+			return n.previous, true
+		}
+		//replace the node by its successor (the min of its next subtree)
+		successor := n.next
+		for successor.previous != nil {
+			successor = successor.previous
+		}
+		next, _ := n.next.delete(successor.key)
+		return balancedRCUNode(successor.key, successor.value, n.previous, next), true
+	}
+}
+
+// ascend() calls fn for each node of the subtree with a key between the bounds lo and hi, in order, until fn returns false
+// (see Node.ascend())
+func (n *rcuNode[K, V]) ascend(lo, hi Bound[K], fn func(key K, value V) bool) bool {
+	if n == nil {
+		return true
+	}
+	if lo.kind == boundUnbounded || n.key > lo.key {
+		if !n.previous.ascend(lo, hi, fn) {
+			return false
+		}
+	}
+	if lo.admitsAbove(n.key) && hi.admitsBelow(n.key) {
+		if !fn(n.key, n.value) {
+			return false
+		}
+	}
+	if hi.kind == boundUnbounded || n.key < hi.key {
+		return n.next.ascend(lo, hi, fn)
+	}
+	return true
+}
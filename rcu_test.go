@@ -0,0 +1,132 @@
+package avlgo
+
+import (
+	"math/rand"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// checkRCUNode() checks the ordering, the depths and the balance of the subtree and returns its depth
+func checkRCUNode(t *testing.T, n *rcuNode[int, int], lo, hi *int) int {
+	t.Helper()
+	if n == nil {
+		return 0
+	}
+	if (lo != nil && n.key <= *lo) || (hi != nil && n.key >= *hi) {
+		t.Fatalf("node %d breaks the ordering", n.key)
+	}
+	previousDepth := checkRCUNode(t, n.previous, lo, &n.key)
+	nextDepth := checkRCUNode(t, n.next, &n.key, hi)
+	if balance := nextDepth - previousDepth; balance < -1 || balance > 1 {
+		t.Fatalf("node %d is unbalanced (%d)", n.key, balance)
+	}
+	depth := 1 + previousDepth
+	if nextDepth > previousDepth {
+		depth = 1 + nextDepth
+	}
+	if n.depth != depth {
+		t.Fatalf("node %d has depth %d, want %d", n.key, n.depth, depth)
+	}
+	return depth
+}
+
+func TestRCUTreeAgainstModel(t *testing.T) {
+	tree := NewRCUTree[int, int]()
+	m := newModel()
+	random := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 5000; i++ {
+		key := random.Intn(300)
+		switch random.Intn(4) {
+		case 0, 1:
+			tree.PutOne(key, i)
+			m.put(key, i)
+		case 2:
+			if deleted, want := tree.Delete(key), m.delete(key); deleted != want {
+				t.Fatalf("Delete(%d) returns %d, want %d", key, deleted, want)
+			}
+		case 3:
+			value, ok := tree.Get(key)
+			want, wantOk := m.values[key]
+			if ok != wantOk || value != want {
+				t.Fatalf("Get(%d) returns %d, %v, want %d, %v", key, value, ok, want, wantOk)
+			}
+		}
+		checkRCUNode(t, tree.root.Load().node, nil, nil)
+		if tree.Size() != len(m.keys) {
+			t.Fatalf("Tree size is %d, want %d", tree.Size(), len(m.keys))
+		}
+	}
+	if values, want := tree.GetFromTo(10, 200, false), m.fromTo(10, 200, false); !reflect.DeepEqual(values, want) {
+		t.Errorf("values is %v, want %v", values, want)
+	}
+
+	value, ok := tree.Compute(1000, func(old int, ok bool) (int, Op) {
+		return old + 1, OpPut
+	})
+	if !ok || value != 1 {
+		t.Errorf("Compute returns %d, %v, want 1, true", value, ok)
+	}
+}
+
+// TestRCUTreeConcurrentReaders runs lock-free readers while writers put and delete keys
+// Every version of the tree holds either nothing or k*2 for the key k. The keys 0..9 are
+// deleted at once and put back in order, so a reader must always see a prefix of them
+func TestRCUTreeConcurrentReaders(t *testing.T) {
+	tree := NewRCUTree[int, int]()
+	for k := 0; k < 10; k++ {
+		tree.PutOne(k, k*2)
+	}
+
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !stop.Load() {
+				for k := 0; k < 1000; k += 7 {
+					if value, ok := tree.Get(k); ok && value != k*2 {
+						t.Errorf("Get(%d) returns %d, want %d", k, value, k*2)
+						return
+					}
+				}
+				for i, value := range tree.GetFromTo(0, 9, true) {
+					if value != i*2 {
+						t.Errorf("GetFromTo sees %d at position %d, want %d", value, i, i*2)
+						return
+					}
+				}
+				previous := -1
+				tree.Ascend(func(key, value int) bool {
+					if key <= previous {
+						t.Errorf("keys are not ordered : %d after %d", key, previous)
+					}
+					previous = key
+					return true
+				})
+			}
+		}()
+	}
+
+	for i := 0; i < 2000; i++ {
+		k := 10 + i%990
+		tree.PutOne(k, k*2)
+		if i%3 == 0 {
+			tree.Delete(k)
+		}
+		if i%100 == 0 {
+			tree.Delete(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+		} else if i%100 == 50 {
+			for k := 0; k < 10; k++ {
+				tree.Compute(k, func(old int, ok bool) (int, Op) {
+					return k * 2, OpPut
+				})
+			}
+		}
+	}
+	stop.Store(true)
+	wg.Wait()
+}
@@ -0,0 +1,396 @@
+// Package server serves a Tree[string, json.RawMessage] over HTTP as an ordered key/value store
+//
+// Routes :
+//
+//	GET    /keys/{key}   returns the JSON value of the key (404 if absent)
+//	PUT    /keys/{key}   puts the JSON value of the request body
+//	DELETE /keys/{key}   deletes the key (404 if absent)
+//	GET    /keys         returns a page of entries : ?from=a&to=z&from_exclusive=true&to_exclusive=true&limit=100&cursor=...
+//	POST   /batch        applies a list of puts and deletes atomically
+//	POST   /snapshot     writes the snapshot file now
+//	GET    /metrics      returns the stats of the tree in the Prometheus text format
+//
+// The errors are returned as {"error": "..."} with the matching HTTP status
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/darthyoh/avlgo/v2"
+)
+
+// DefaultLimit is the size of a page of entries when the request doesn't give one, and MaxLimit the largest allowed
+const (
+	DefaultLimit = 100
+	MaxLimit     = 10000
+)
+
+// MaxBodySize is the largest request body accepted (values and batches)
+const MaxBodySize = 8 << 20
+
+// ErrNoSnapshot is returned by Snapshot() on a server without snapshot file
+var ErrNoSnapshot = errors.New("no snapshot file")
+
+// Tree is the tree served by a Server
+type Tree = avlgo.Tree[string, json.RawMessage]
+
+// Options are the options of a Server
+type Options struct {
+	SnapshotFile     string        //file where the tree is encoded (no persistence if empty)
+	SnapshotInterval time.Duration //the tree is encoded every interval by Start() if it changed (never if zero)
+}
+
+// Server is an http.Handler serving a tree
+type Server struct {
+	tree          *Tree
+	options       Options
+	metrics       http.Handler
+	version       atomic.Uint64 //number of changes of the tree, counted by its OnPut() and OnDelete() callbacks
+	snapshotMutex sync.Mutex    //only one snapshot is written at a time
+	snapshotted   uint64        //version of the last snapshot, guarded by snapshotMutex
+}
+
+// New() returns a Server for the tree
+// Every change of the tree is counted, so the periodic snapshot sees the changes made through Tree() too
+func New(tree *Tree, options Options) *Server {
+	s := &Server{tree: tree, options: options}
+	s.metrics = avlgo.MetricsHandler(map[string]avlgo.StatsReporter{"server": tree})
+	tree.OnPut(func(key string, value json.RawMessage) {
+		s.version.Add(1)
+	})
+	tree.OnDelete(func(key string, value json.RawMessage) {
+		s.version.Add(1)
+	})
+	return s
+}
+
+// Open() returns a Server for the tree decoded from the snapshot file of the options,
+// or for an empty tree if the file doesn't exist yet
+func Open(options Options) (*Server, error) {
+	if options.SnapshotFile == "" {
+		return New(avlgo.NewTree[string, json.RawMessage](), options), nil
+	}
+	if _, err := os.Stat(options.SnapshotFile); errors.Is(err, os.ErrNotExist) {
+		return New(avlgo.NewTree[string, json.RawMessage](), options), nil
+	}
+	tree, err := avlgo.Decode[string, json.RawMessage](options.SnapshotFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open snapshot : %s", err)
+	}
+	return New(tree, options), nil
+}
+
+// Tree() returns the served tree
+func (s *Server) Tree() *Tree {
+	return s.tree
+}
+
+// Snapshot() encodes the tree in the snapshot file. The file is replaced at once :
+// a crash while writing leaves the previous snapshot
+func (s *Server) Snapshot() error {
+	if s.options.SnapshotFile == "" {
+		return ErrNoSnapshot
+	}
+	s.snapshotMutex.Lock()
+	defer s.snapshotMutex.Unlock()
+
+	//the clone is taken under the read lock of the tree, the encoding is done without it
+	version := s.version.Load()
+	clone := s.tree.Clone()
+	temporary := s.options.SnapshotFile + ".tmp"
+	if err := clone.Encode(temporary); err != nil {
+		os.Remove(temporary)
+		return fmt.Errorf("unable to write snapshot : %s", err)
+	}
+	if err := os.Rename(temporary, s.options.SnapshotFile); err != nil {
+		return fmt.Errorf("unable to write snapshot : %s", err)
+	}
+	s.snapshotted = version
+	return nil
+}
+
+// Start() starts a goroutine writing a snapshot every SnapshotInterval if the tree changed
+// and calling onError with the failures (onError may be nil)
+// Call the returned stop() function to stop it : it writes a last snapshot if the tree changed
+func (s *Server) Start(onError func(err error)) (stop func()) {
+	if s.options.SnapshotFile == "" || s.options.SnapshotInterval <= 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	ticker := time.NewTicker(s.options.SnapshotInterval)
+
+	snapshot := func() {
+		if err := s.snapshotIfChanged(); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+	go func() {
+		defer close(stopped)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				snapshot()
+			case <-done:
+				snapshot()
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-stopped
+	}
+}
+
+// snapshotIfChanged() writes a snapshot if the tree changed since the last one
+func (s *Server) snapshotIfChanged() error {
+	s.snapshotMutex.Lock()
+	changed := s.version.Load() != s.snapshotted
+	s.snapshotMutex.Unlock()
+	if !changed {
+		return nil
+	}
+	return s.Snapshot()
+}
+
+// ServeHTTP() routes the request (it implements http.Handler)
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/keys/"):
+		key := strings.TrimPrefix(r.URL.Path, "/keys/")
+		if key == "" {
+			writeError(w, http.StatusBadRequest, "missing key")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			s.get(w, key)
+		case http.MethodPut:
+			s.put(w, r, key)
+		case http.MethodDelete:
+			s.delete(w, key)
+		default:
+			methodNotAllowed(w, "GET, PUT, DELETE")
+		}
+	case r.URL.Path == "/keys":
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			methodNotAllowed(w, "GET")
+			return
+		}
+		s.list(w, r)
+	case r.URL.Path == "/batch":
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, "POST")
+			return
+		}
+		s.batch(w, r)
+	case r.URL.Path == "/snapshot":
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, "POST")
+			return
+		}
+		s.snapshot(w)
+	case r.URL.Path == "/metrics":
+		s.metrics.ServeHTTP(w, r)
+	default:
+		writeError(w, http.StatusNotFound, "unknown path %s", r.URL.Path)
+	}
+}
+
+func (s *Server) get(w http.ResponseWriter, key string) {
+	value, ok := s.tree.Get(key)
+	if !ok {
+		writeError(w, http.StatusNotFound, "key %q not found", key)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(value)
+}
+
+func (s *Server) put(w http.ResponseWriter, r *http.Request, key string) {
+	value, err := readBody(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	if !json.Valid(value) {
+		writeError(w, http.StatusBadRequest, "the value of %q is not valid JSON", key)
+		return
+	}
+	s.tree.PutOne(key, json.RawMessage(value))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) delete(w http.ResponseWriter, key string) {
+	if s.tree.Delete(key) == 0 {
+		writeError(w, http.StatusNotFound, "key %q not found", key)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// entry is an entry of the tree in the pages
+type entry struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// page is the response of GET /keys. Next is the cursor of the following page (empty for the last page)
+type page struct {
+	Entries []entry `json:"entries"`
+	Next    string  `json:"next,omitempty"`
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	lo, err := bound(query, "from")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	hi, err := bound(query, "to")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	limit := DefaultLimit
+	if query.Has("limit") {
+		if limit, err = strconv.Atoi(query.Get("limit")); err != nil || limit <= 0 || limit > MaxLimit {
+			writeError(w, http.StatusBadRequest, "limit must be between 1 and %d", MaxLimit)
+			return
+		}
+	}
+
+	entries, next, err := s.tree.GetRangePage(lo, hi, limit, query.Get("cursor"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	response := page{Entries: make([]entry, 0, len(entries)), Next: next}
+	for _, e := range entries {
+		response.Entries = append(response.Entries, entry{Key: e.Key, Value: e.Value})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// bound() returns the bound given by the name and name_exclusive parameters (unbounded if name is absent)
+func bound(query url.Values, name string) (avlgo.Bound[string], error) {
+	values, ok := query[name]
+	if !ok {
+		return avlgo.Unbounded[string](), nil
+	}
+	exclusive := false
+	if flags, ok := query[name+"_exclusive"]; ok {
+		var err error
+		if exclusive, err = strconv.ParseBool(flags[0]); err != nil {
+			return avlgo.Bound[string]{}, fmt.Errorf("%s_exclusive must be true or false", name)
+		}
+	}
+	if exclusive {
+		return avlgo.Exclusive(values[0]), nil
+	}
+	return avlgo.Inclusive(values[0]), nil
+}
+
+// operation is an operation of a batch : {"op": "put", "key": "k", "value": v} or {"op": "delete", "key": "k"}
+type operation struct {
+	Op    string          `json:"op"`
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// batchResult is the response of POST /batch
+type batchResult struct {
+	Put     int `json:"put"`
+	Deleted int `json:"deleted"`
+}
+
+// batch() applies the operations of the request body {"ops": [...]} : all of them are checked
+// before any is applied, and they are applied with the tree locked once
+func (s *Server) batch(w http.ResponseWriter, r *http.Request) {
+	body, err := readBody(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	var request struct {
+		Ops []operation `json:"ops"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeError(w, http.StatusBadRequest, "unable to decode batch : %s", err)
+		return
+	}
+
+	ops := make([]avlgo.BatchOp[string, json.RawMessage], 0, len(request.Ops))
+	for i, op := range request.Ops {
+		switch op.Op {
+		case "put":
+			if len(op.Value) == 0 {
+				writeError(w, http.StatusBadRequest, "operation %d : missing value", i)
+				return
+			}
+			ops = append(ops, avlgo.BatchOp[string, json.RawMessage]{Key: op.Key, Value: op.Value})
+		case "delete":
+			ops = append(ops, avlgo.BatchOp[string, json.RawMessage]{Key: op.Key, Delete: true})
+		default:
+			writeError(w, http.StatusBadRequest, "operation %d : unknown op %q (put or delete)", i, op.Op)
+			return
+		}
+	}
+
+	put, deleted, err := s.tree.Batch(ops...)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, batchResult{Put: put, Deleted: deleted})
+}
+
+func (s *Server) snapshot(w http.ResponseWriter) {
+	if err := s.Snapshot(); errors.Is(err, ErrNoSnapshot) {
+		writeError(w, http.StatusConflict, "%s", err)
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, "%s", err)
+	} else {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// readBody() reads the request body, up to MaxBodySize
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	b, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read body : %s", err)
+	}
+	return b, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...any) {
+	writeJSON(w, status, map[string]string{"error": fmt.Sprintf(format, args...)})
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed string) {
+	w.Header().Set("Allow", allowed)
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed (%s)", allowed)
+}
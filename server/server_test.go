@@ -0,0 +1,179 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/darthyoh/avlgo/v2"
+)
+
+// do() sends a request to the server and returns the status and the body of the response
+func do(t *testing.T, s http.Handler, method, target, body string) (int, string) {
+	t.Helper()
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, request)
+	b, _ := io.ReadAll(recorder.Result().Body)
+	return recorder.Code, string(b)
+}
+
+func TestKeys(t *testing.T) {
+	s := New(avlgo.NewTree[string, json.RawMessage](), Options{})
+
+	if status, _ := do(t, s, "PUT", "/keys/user:1", `{"name": "ada"}`); status != http.StatusNoContent {
+		t.Errorf("PUT status is %d, want %d", status, http.StatusNoContent)
+	}
+	if status, body := do(t, s, "GET", "/keys/user:1", ""); status != http.StatusOK || body != `{"name": "ada"}` {
+		t.Errorf("GET returns %d %s", status, body)
+	}
+	if status, _ := do(t, s, "PUT", "/keys/user:2", `{"name": `); status != http.StatusBadRequest {
+		t.Errorf("PUT of invalid JSON status is %d, want %d", status, http.StatusBadRequest)
+	}
+	if status, body := do(t, s, "GET", "/keys/user:2", ""); status != http.StatusNotFound || body != "{\"error\":\"key \\\"user:2\\\" not found\"}\n" {
+		t.Errorf("GET of a missing key returns %d %s", status, body)
+	}
+	if status, _ := do(t, s, "DELETE", "/keys/user:1", ""); status != http.StatusNoContent {
+		t.Errorf("DELETE status is %d, want %d", status, http.StatusNoContent)
+	}
+	if status, _ := do(t, s, "DELETE", "/keys/user:1", ""); status != http.StatusNotFound {
+		t.Errorf("DELETE of a missing key status is %d, want %d", status, http.StatusNotFound)
+	}
+	if status, _ := do(t, s, "POST", "/keys/user:1", "1"); status != http.StatusMethodNotAllowed {
+		t.Errorf("POST on a key status is %d, want %d", status, http.StatusMethodNotAllowed)
+	}
+	if status, _ := do(t, s, "GET", "/keys/", ""); status != http.StatusBadRequest {
+		t.Errorf("GET without key status is %d, want %d", status, http.StatusBadRequest)
+	}
+	if status, _ := do(t, s, "GET", "/other", ""); status != http.StatusNotFound {
+		t.Errorf("GET of an unknown path status is %d, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestRangePages(t *testing.T) {
+	s := New(avlgo.NewTree[string, json.RawMessage](), Options{})
+	for i := 0; i < 10; i++ {
+		s.Tree().PutOne(fmt.Sprintf("k%d", i), json.RawMessage(fmt.Sprint(i)))
+	}
+
+	var keys []string
+	cursor, pages := "", 0
+	for {
+		status, body := do(t, s, "GET", "/keys?from=k2&from_exclusive=true&to=k8&limit=2&cursor="+cursor, "")
+		if status != http.StatusOK {
+			t.Fatalf("GET /keys returns %d %s", status, body)
+		}
+		var p page
+		if err := json.Unmarshal([]byte(body), &p); err != nil {
+			t.Fatalf("unable to decode page %s : %s", body, err)
+		}
+		for _, e := range p.Entries {
+			keys = append(keys, e.Key)
+		}
+		pages++
+		if cursor = p.Next; cursor == "" {
+			break
+		}
+	}
+	if strings.Join(keys, ",") != "k3,k4,k5,k6,k7,k8" || pages != 3 {
+		t.Errorf("keys are %v in %d pages, want k3 to k8 in 3 pages", keys, pages)
+	}
+
+	if _, body := do(t, s, "GET", "/keys?to=k1", ""); body != "{\"entries\":[{\"key\":\"k0\",\"value\":0},{\"key\":\"k1\",\"value\":1}]}\n" {
+		t.Errorf("GET /keys returns %s", body)
+	}
+	for _, query := range []string{"limit=0", "limit=x", "from=b&to=a", "to=a&to_exclusive=maybe", "cursor=!"} {
+		if status, _ := do(t, s, "GET", "/keys?"+query, ""); status != http.StatusBadRequest {
+			t.Errorf("GET /keys?%s status is %d, want %d", query, status, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	s := New(avlgo.NewTree[string, json.RawMessage](), Options{})
+	s.Tree().PutOne("a", json.RawMessage(`1`))
+
+	status, body := do(t, s, "POST", "/batch", `{"ops": [
+		{"op": "put", "key": "b", "value": [2]},
+		{"op": "delete", "key": "a"},
+		{"op": "delete", "key": "z"},
+		{"op": "put", "key": "c", "value": "three"}
+	]}`)
+	if status != http.StatusOK || body != "{\"put\":2,\"deleted\":1}\n" {
+		t.Errorf("POST /batch returns %d %s", status, body)
+	}
+	if keys := s.Tree().PrintKeys(0); strings.Join(keys, ",") != "b,c" {
+		t.Errorf("keys are %v, want [b c]", keys)
+	}
+
+	//an invalid operation rejects the whole batch
+	for _, batch := range []string{
+		`{"ops": [{"op": "delete", "key": "b"}, {"op": "get", "key": "c"}]}`,
+		`{"ops": [{"op": "delete", "key": "b"}, {"op": "put", "key": "c"}]}`,
+		`{"ops": [{"op": "put", "key": "c", "value": nope}]}`,
+	} {
+		if status, _ := do(t, s, "POST", "/batch", batch); status != http.StatusBadRequest {
+			t.Errorf("POST /batch %s status is %d, want %d", batch, status, http.StatusBadRequest)
+		}
+	}
+	if s.Tree().Size() != 2 {
+		t.Errorf("size is %d, want 2", s.Tree().Size())
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tree.gob")
+	s, err := Open(Options{SnapshotFile: file, SnapshotInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Open shouldn't return an error. %s is returned", err)
+	}
+	stop := s.Start(nil)
+	do(t, s, "PUT", "/keys/a", `"A"`)
+	do(t, s, "PUT", "/keys/b", `"B"`)
+	if status, _ := do(t, s, "POST", "/snapshot", ""); status != http.StatusNoContent {
+		t.Errorf("POST /snapshot status is %d, want %d", status, http.StatusNoContent)
+	}
+	do(t, s, "DELETE", "/keys/a", "")
+	stop() //the last change is written when stopping
+
+	s, err = Open(Options{SnapshotFile: file, SnapshotInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Open shouldn't return an error. %s is returned", err)
+	}
+	if status, body := do(t, s, "GET", "/keys/b", ""); status != http.StatusOK || body != `"B"` {
+		t.Errorf("GET returns %d %s after reopening", status, body)
+	}
+	if status, _ := do(t, s, "GET", "/keys/a", ""); status != http.StatusNotFound {
+		t.Errorf("GET of a deleted key status is %d after reopening, want %d", status, http.StatusNotFound)
+	}
+
+	//the changes made directly in the tree are written too
+	stop = s.Start(nil)
+	s.Tree().PutOne("c", json.RawMessage(`"C"`))
+	stop()
+	tree, err := avlgo.Decode[string, json.RawMessage](file)
+	if err != nil {
+		t.Fatalf("Decode shouldn't return an error. %s is returned", err)
+	}
+	if value, ok := tree.Get("c"); !ok || string(value) != `"C"` {
+		t.Errorf("Get returns %s, %v after a direct change, want \"C\", true", value, ok)
+	}
+
+	if status, _ := do(t, New(avlgo.NewTree[string, json.RawMessage](), Options{}), "POST", "/snapshot", ""); status != http.StatusConflict {
+		t.Errorf("POST /snapshot without file status is %d, want %d", status, http.StatusConflict)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	s := New(avlgo.NewTree[string, json.RawMessage](), Options{})
+	do(t, s, "PUT", "/keys/a", `1`)
+	if status, body := do(t, s, "GET", "/metrics", ""); status != http.StatusOK || !strings.Contains(body, `avlgo_size{tree="server"} 1`) {
+		t.Errorf("GET /metrics returns %d %s", status, body)
+	}
+}
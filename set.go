@@ -0,0 +1,197 @@
+package avlgo
+
+// Set is an ordered set of keys, built on the same AVL tree than Tree
+// Its nodes hold a struct{} value, which takes no memory
+// Like a Tree, a Set is safe for concurrent use. Operations between two sets read each set
+// separately (they are not an atomic snapshot of both sets)
+type Set[K Ordered] struct {
+	tree *Tree[K, struct{}]
+}
+
+// NewSet() returns a new Set holding the keys
+// If a key is NaN, no set is returned and ErrNaNKey is returned, like Add()
+func NewSet[K Ordered](keys ...K) (*Set[K], error) {
+	s := &Set[K]{tree: NewTree[K, struct{}]()}
+	if _, err := s.Add(keys...); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// newSetFromSorted() returns a new Set holding the ordered (and unique) keys, built in O(n)
+func newSetFromSorted[K Ordered](keys []K) *Set[K] {
+	return &Set[K]{tree: newTreeFromSorted(keys, make([]struct{}, len(keys)))}
+}
+
+// Add() adds the keys to the set and returns the number of keys which were not already present
+// If a key is NaN, no key is added and ErrNaNKey is returned
+func (s *Set[K]) Add(keys ...K) (added int, err error) {
+	for _, k := range keys {
+		if isNaN(k) {
+			return 0, ErrNaNKey
+		}
+	}
+	s.tree.lock()
+	defer s.tree.unlock()
+
+	for _, k := range keys {
+		var node, parent *Node[K, struct{}]
+		if s.tree.RootNode != nil {
+			node, parent = s.tree.RootNode.search(k, &s.tree.probe)
+		}
+		if node == nil {
+			s.tree.storeAt(nil, parent, k, struct{}{})
+			added++
+		}
+	}
+	return added, nil
+}
+
+// Remove() removes the keys from the set and returns the number of keys which were present
+func (s *Set[K]) Remove(keys ...K) int {
+	return s.tree.Delete(keys...)
+}
+
+// Contains() returns true if the key is in the set
+func (s *Set[K]) Contains(key K) bool {
+	_, ok := s.tree.Get(key)
+	return ok
+}
+
+// Size() returns the number of keys in the set
+func (s *Set[K]) Size() int {
+	s.tree.rlock()
+	defer s.tree.runlock()
+	return s.tree.count
+}
+
+// Keys() returns the ordered keys of the set
+func (s *Set[K]) Keys() (keys []K) {
+	s.Ascend(func(key K) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Ascend() calls fn for each key of the set, in order, until fn returns false
+// The set is read-locked during the walk, so fn must not modify it
+func (s *Set[K]) Ascend(fn func(key K) bool) {
+	s.tree.Ascend(func(key K, _ struct{}) bool {
+		return fn(key)
+	})
+}
+
+// Min() returns the smallest key of the set (ok is false if the set is empty)
+func (s *Set[K]) Min() (key K, ok bool) {
+	s.tree.rlock()
+	defer s.tree.runlock()
+	if s.tree.RootNode == nil {
+		return key, false
+	}
+	return s.tree.RootNode.min().Key, true
+}
+
+// Max() returns the biggest key of the set (ok is false if the set is empty)
+func (s *Set[K]) Max() (key K, ok bool) {
+	s.tree.rlock()
+	defer s.tree.runlock()
+	if s.tree.RootNode == nil {
+		return key, false
+	}
+	return s.tree.RootNode.max().Key, true
+}
+
+// Floor() returns the biggest key of the set smaller or equal to key (ok is false if there is none)
+func (s *Set[K]) Floor(key K) (floor K, ok bool) {
+	s.tree.rlock()
+	defer s.tree.runlock()
+	if node := s.tree.RootNode.floor(key); node != nil {
+		return node.Key, true
+	}
+	return floor, false
+}
+
+// Ceiling() returns the smallest key of the set bigger or equal to key (ok is false if there is none)
+func (s *Set[K]) Ceiling(key K) (ceiling K, ok bool) {
+	s.tree.rlock()
+	defer s.tree.runlock()
+	if node := s.tree.RootNode.ceiling(key); node != nil {
+		return node.Key, true
+	}
+	return ceiling, false
+}
+
+// Union() returns a new Set with the keys present in s or in other. It runs in O(n+m)
+func (s *Set[K]) Union(other *Set[K]) *Set[K] {
+	return newSetFromSorted(mergeKeys(s.Keys(), other.Keys(), true, true, true))
+}
+
+// Intersect() returns a new Set with the keys present in s and in other. It runs in O(n+m)
+func (s *Set[K]) Intersect(other *Set[K]) *Set[K] {
+	return newSetFromSorted(mergeKeys(s.Keys(), other.Keys(), false, false, true))
+}
+
+// Difference() returns a new Set with the keys present in s but not in other. It runs in O(n+m)
+func (s *Set[K]) Difference(other *Set[K]) *Set[K] {
+	return newSetFromSorted(mergeKeys(s.Keys(), other.Keys(), true, false, false))
+}
+
+// SymmetricDifference() returns a new Set with the keys present in only one of s and other. It runs in O(n+m)
+func (s *Set[K]) SymmetricDifference(other *Set[K]) *Set[K] {
+	return newSetFromSorted(mergeKeys(s.Keys(), other.Keys(), true, true, false))
+}
+
+// IsSubset() returns true if every key of s is present in other
+func (s *Set[K]) IsSubset(other *Set[K]) bool {
+	keys := s.Keys()
+	return len(mergeKeys(keys, other.Keys(), false, false, true)) == len(keys)
+}
+
+// Equal() returns true if s and other hold the same keys
+func (s *Set[K]) Equal(other *Set[K]) bool {
+	keys, otherKeys := s.Keys(), other.Keys()
+	if len(keys) != len(otherKeys) {
+		return false
+	}
+	for i := range keys {
+		if keys[i] != otherKeys[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeKeys() merges two ordered slices of unique keys, keeping the keys present only in a (onlyA),
+// only in b (onlyB) and in both (both). The result is ordered
+func mergeKeys[K Ordered](a, b []K, onlyA, onlyB, both bool) []K {
+	merged := make([]K, 0)
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			if onlyA {
+				merged = append(merged, a[i])
+			}
+			i++
+		case a[i] > b[j]:
+			if onlyB {
+				merged = append(merged, b[j])
+			}
+			j++
+		default:
+			if both {
+				merged = append(merged, a[i])
+			}
+			i++
+			j++
+		}
+	}
+	if onlyA {
+		merged = append(merged, a[i:]...)
+	}
+	if onlyB {
+		merged = append(merged, b[j:]...)
+	}
+	return merged
+}
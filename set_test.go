@@ -0,0 +1,101 @@
+package avlgo
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+func TestSet(t *testing.T) {
+	set := newSet(5, 1, 3, 3, 9)
+	if set.Size() != 4 {
+		t.Errorf("Set size is %d, want 4", set.Size())
+	}
+	if added, err := set.Add(7, 9); added != 1 || err != nil {
+		t.Errorf("Add returns %d, %v, want 1, nil", added, err)
+	}
+	if removed := set.Remove(1, 2); removed != 1 {
+		t.Errorf("Remove removes %d keys, want 1", removed)
+	}
+	if !set.Contains(7) || set.Contains(1) {
+		t.Errorf("Contains should find 7 and not 1")
+	}
+	if keys := set.Keys(); !reflect.DeepEqual(keys, []int{3, 5, 7, 9}) {
+		t.Errorf("keys is %v, want %v", keys, []int{3, 5, 7, 9})
+	}
+
+	if key, ok := set.Floor(6); !ok || key != 5 {
+		t.Errorf("Floor(6) returns %d, %v, want 5, true", key, ok)
+	}
+	if key, ok := set.Floor(7); !ok || key != 7 {
+		t.Errorf("Floor(7) returns %d, %v, want 7, true", key, ok)
+	}
+	if _, ok := set.Floor(2); ok {
+		t.Errorf("Floor(2) shouldn't find a key")
+	}
+	if key, ok := set.Ceiling(6); !ok || key != 7 {
+		t.Errorf("Ceiling(6) returns %d, %v, want 7, true", key, ok)
+	}
+	if _, ok := set.Ceiling(10); ok {
+		t.Errorf("Ceiling(10) shouldn't find a key")
+	}
+	if key, ok := set.Min(); !ok || key != 3 {
+		t.Errorf("Min returns %d, %v, want 3, true", key, ok)
+	}
+	if key, ok := set.Max(); !ok || key != 9 {
+		t.Errorf("Max returns %d, %v, want 9, true", key, ok)
+	}
+	if _, ok := newSet[int]().Min(); ok {
+		t.Errorf("Min shouldn't find a key in an empty set")
+	}
+
+	if unsafe.Sizeof(Node[int, struct{}]{}) != unsafe.Sizeof(Node[int, bool]{})-unsafe.Sizeof(uintptr(0)) {
+		t.Errorf("the nodes of a set shouldn't store a value")
+	}
+}
+
+func TestSetAlgebra(t *testing.T) {
+	a := newSet(1, 2, 3, 4, 5)
+	b := newSet(4, 5, 6, 7)
+
+	tests := []struct {
+		name string
+		set  *Set[int]
+		want []int
+	}{
+		{"Union", a.Union(b), []int{1, 2, 3, 4, 5, 6, 7}},
+		{"Intersect", a.Intersect(b), []int{4, 5}},
+		{"Difference", a.Difference(b), []int{1, 2, 3}},
+		{"SymmetricDifference", a.SymmetricDifference(b), []int{1, 2, 3, 6, 7}},
+		{"Empty", a.Difference(a), nil},
+	}
+	for _, test := range tests {
+		if keys := test.set.Keys(); !reflect.DeepEqual(keys, test.want) {
+			t.Errorf("%s keys is %v, want %v", test.name, keys, test.want)
+		}
+		if test.set.Size() != len(test.want) {
+			t.Errorf("%s size is %d, want %d", test.name, test.set.Size(), len(test.want))
+		}
+		if root := test.set.tree.RootNode; root != nil {
+			if _, ok := root.isValid(); !ok {
+				t.Errorf("%s is not a valid AVL tree", test.name)
+			}
+		}
+	}
+
+	if !a.Intersect(b).IsSubset(a) || a.IsSubset(b) || !newSet[int]().IsSubset(a) {
+		t.Errorf("IsSubset returns a wrong result")
+	}
+	if !a.Equal(newSet(5, 4, 3, 2, 1)) || a.Equal(b) || a.Equal(newSet(1, 2, 3, 4)) {
+		t.Errorf("Equal returns a wrong result")
+	}
+}
+
+// newSet() returns NewSet(keys...), for keys which can't be NaN
+func newSet[K Ordered](keys ...K) *Set[K] {
+	s, err := NewSet(keys...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
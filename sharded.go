@@ -0,0 +1,753 @@
+package avlgo
+
+import (
+	"expvar"
+	"math/bits"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ShardedTree is a Tree split in shards by ranges of keys
+// Each shard is a Tree with its own lock, so writers on different shards don't wait for each other
+// Range queries and iterations walk the shards in order (they are not a snapshot of the whole tree)
+// It has the methods of a Tree, except OnEvict() : a ShardedTree is unbounded. The methods changing several keys
+// (Batch(), DeleteFunc(), Extract()...) lock every shard they change, so they are atomic like on a Tree
+// The observers, the expiration callback and the tracer are shared by the shards : they are called in the order
+// of the changes of each key, but the tracer may be called by several shards at once
+type ShardedTree[K Ordered, V any] struct {
+	shards       atomic.Pointer[[]*shard[K, V]] //ordered shards, replaced (never modified) when a shard is split
+	maxShardSize int                            //a shard holding more entries is split in two (0 to never split)
+	reshardMutex sync.Mutex                     //serializes the splits
+}
+
+// shard is a range of keys of a ShardedTree : every key is smaller than upper (if hasUpper)
+// and bigger or equal to the upper bound of the previous shard
+type shard[K Ordered, V any] struct {
+	tree     *Tree[K, V] //the entries of the shard, locked by the lock methods of the tree
+	upper    K
+	hasUpper bool
+	retired  bool //set (with the tree locked) when the shard has been split : operations must route again
+}
+
+// NewShardedTree() returns an empty ShardedTree whose shards are split at the boundaries (n boundaries give n+1 shards)
+// A shard holding more than maxShardSize entries is split in two while the tree is used (0 to never split)
+func NewShardedTree[K Ordered, V any](boundaries []K, maxShardSize int) *ShardedTree[K, V] {
+	sorted := append([]K(nil), boundaries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	shards := make([]*shard[K, V], 0, len(sorted)+1)
+	for i, boundary := range sorted {
+		if i > 0 && boundary == sorted[i-1] {
+			continue
+		}
+		shards = append(shards, &shard[K, V]{tree: NewTree[K, V](), upper: boundary, hasUpper: true})
+	}
+	shards = append(shards, &shard[K, V]{tree: NewTree[K, V]()})
+
+	st := &ShardedTree[K, V]{maxShardSize: maxShardSize}
+	st.shards.Store(&shards)
+	return st
+}
+
+// NewShardedTreeFromSamples() returns an empty ShardedTree with (at most) the wanted number of shards,
+// whose boundaries are the quantiles of the sample keys
+func NewShardedTreeFromSamples[K Ordered, V any](samples []K, shards int, maxShardSize int) *ShardedTree[K, V] {
+	sorted := append([]K(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	boundaries := make([]K, 0, shards)
+	for i := 1; i < shards && len(sorted) > 0; i++ {
+		boundaries = append(boundaries, sorted[i*len(sorted)/shards])
+	}
+	return NewShardedTree[K, V](boundaries, maxShardSize)
+}
+
+// Shards() returns the number of shards
+func (st *ShardedTree[K, V]) Shards() int {
+	return len(*st.shards.Load())
+}
+
+// Size() returns the number of entries in all the shards
+func (st *ShardedTree[K, V]) Size() (size int) {
+	st.eachShard(func(s *shard[K, V]) {
+		size += s.tree.count
+	})
+	return size
+}
+
+// Depth() returns the biggest depth of the shards
+func (st *ShardedTree[K, V]) Depth() (depth int) {
+	st.eachShard(func(s *shard[K, V]) {
+		if s.tree.RootNode != nil && s.tree.RootNode.Depth() > depth {
+			depth = s.tree.RootNode.Depth()
+		}
+	})
+	return depth
+}
+
+// Get() returns the value present in the tree for the key
+func (st *ShardedTree[K, V]) Get(key K) (value V, ok bool) {
+	s := st.rlockShardOf(key)
+	defer s.tree.runlock()
+
+	if s.tree.RootNode == nil {
+		return
+	}
+	if foundNode := s.tree.RootNode.Get(key); foundNode != nil {
+		return foundNode.Value, true
+	}
+	return
+}
+
+// PutOne() adds one element in the tree, replacing the value if the key is already present
+// Only the shard of the key is locked. A NaN key is not put : it returns false
+func (st *ShardedTree[K, V]) PutOne(key K, value V) bool {
+	if isNaN(key) {
+		return false
+	}
+	s, expired := st.lockShard(key)
+	s.tree.clearDeadline(key)
+	s.tree.putNode(key, value)
+	st.unlockShard(s, expired)
+	return true
+}
+
+// PutWithTTL() acts like PutOne() but the entry expires after ttl, like Tree.PutWithTTL()
+func (st *ShardedTree[K, V]) PutWithTTL(key K, value V, ttl time.Duration) bool {
+	if isNaN(key) {
+		return false
+	}
+	s, expired := st.lockShard(key)
+	s.tree.putWithTTL(key, value, ttl)
+	st.unlockShard(s, expired)
+	return true
+}
+
+// TTL() returns the remaining time to live of the key, like Tree.TTL()
+func (st *ShardedTree[K, V]) TTL(key K) (remaining time.Duration, ok bool) {
+	s := st.rlockShardOf(key)
+	defer s.tree.runlock()
+	return s.tree.ttl(key)
+}
+
+// SetClock() replaces the clock used to expire the keys of every shard (time.Now by default)
+func (st *ShardedTree[K, V]) SetClock(now func() time.Time) {
+	st.configure(func(t *Tree[K, V]) {
+		t.expiration.now = now
+	})
+}
+
+// OnExpire() registers a callback called (outside of the locks) for every entry removed because its TTL expired
+func (st *ShardedTree[K, V]) OnExpire(fn func(key K, value V)) {
+	st.configure(func(t *Tree[K, V]) {
+		t.expiration.onExpire = fn
+	})
+}
+
+// RemoveExpired() removes every expired entry and returns the number of removed entries, like Tree.RemoveExpired()
+func (st *ShardedTree[K, V]) RemoveExpired() int {
+	return st.writeShards(allShards[K, V], func(shards []*shard[K, V]) {})
+}
+
+// StartJanitor() starts a goroutine removing the expired entries every interval, like Tree.StartJanitor()
+func (st *ShardedTree[K, V]) StartJanitor(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				st.RemoveExpired()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// OnPut() registers a callback called for every key put in the tree, like Tree.OnPut()
+func (st *ShardedTree[K, V]) OnPut(fn func(key K, value V)) {
+	st.observe().onPut(fn)
+}
+
+// OnDelete() registers a callback called for every key removed from the tree, like Tree.OnDelete()
+func (st *ShardedTree[K, V]) OnDelete(fn func(key K, value V)) {
+	st.observe().onDelete(fn)
+}
+
+// Watch() returns a channel receiving the changes of the keys between from and to (bounds included), like Tree.Watch()
+func (st *ShardedTree[K, V]) Watch(from, to K, options WatchOptions) (events <-chan Event[K, V], cancel func()) {
+	return st.observe().watch(from, to, options)
+}
+
+// SetTracer() sets the tracer of every shard, like Tree.SetTracer()
+// The shards are changed concurrently : the tracer must be safe for concurrent use (a Recorder is not)
+func (st *ShardedTree[K, V]) SetTracer(tracer Tracer[K, V]) {
+	st.configure(func(t *Tree[K, V]) {
+		t.probe.tracer = tracer
+	})
+}
+
+// PublishExpvar() publishes the Stats of the tree as an expvar variable, like Tree.PublishExpvar()
+func (st *ShardedTree[K, V]) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return st.Stats()
+	}))
+}
+
+// Put() adds elements `items` to the tree in a concurrent way, like Tree.Put()
+func (st *ShardedTree[K, V]) Put(items ...struct {
+	key   K
+	value V
+}) (addedItems int) {
+	var wg sync.WaitGroup
+	var added atomic.Int64
+	for _, item := range items {
+		wg.Add(1)
+		go func(key K, value V) {
+			defer wg.Done()
+			if st.PutOne(key, value) {
+				added.Add(1)
+			}
+		}(item.key, item.value)
+	}
+	wg.Wait()
+	return int(added.Load())
+}
+
+// Delete() removes the nodes corresponding to the passed keys and returns the number of nodes deleted
+func (st *ShardedTree[K, V]) Delete(keys ...K) int {
+	deleted := 0
+	for _, k := range keys {
+		s, expired := st.lockShard(k)
+		deleted += s.tree.deleteKeys([]K{k})
+		st.unlockShard(s, expired)
+	}
+	return deleted
+}
+
+// Batch() applies the operations in order with their shards locked at once, like Tree.Batch()
+func (st *ShardedTree[K, V]) Batch(ops ...BatchOp[K, V]) (put, deleted int, err error) {
+	if err = validOps(ops); err != nil {
+		return 0, 0, err
+	}
+	var shards []*shard[K, V]
+	st.writeShards(func(current []*shard[K, V]) []*shard[K, V] {
+		//only the shards of the keys are locked
+		shards = current
+		used := make([]bool, len(current))
+		for _, op := range ops {
+			used[route(current, op.Key)] = true
+		}
+		picked := make([]*shard[K, V], 0)
+		for i, s := range current {
+			if used[i] {
+				picked = append(picked, s)
+			}
+		}
+		return picked
+	}, func([]*shard[K, V]) {
+		put, deleted = 0, 0
+		for _, op := range ops {
+			opPut, opDeleted := shards[route(shards, op.Key)].tree.batch([]BatchOp[K, V]{op})
+			put, deleted = put+opPut, deleted+opDeleted
+		}
+	})
+	return put, deleted, nil
+}
+
+// DeleteFunc() removes the entries for which pred returns true and returns the number of removed entries, like Tree.DeleteFunc()
+// Every shard is locked during the operation
+func (st *ShardedTree[K, V]) DeleteFunc(pred func(key K, value V) bool) (removed int) {
+	st.writeShards(allShards[K, V], func(shards []*shard[K, V]) {
+		removed = 0
+		for _, s := range shards {
+			removed += s.tree.deleteFunc(pred)
+		}
+	})
+	return removed
+}
+
+// Retain() keeps only the entries for which pred returns true and returns the number of removed entries, like DeleteFunc()
+func (st *ShardedTree[K, V]) Retain(pred func(key K, value V) bool) int {
+	return st.DeleteFunc(negate(pred))
+}
+
+// Extract() removes the keys between the bounds lo and hi and returns them in a new Tree, like Tree.Extract()
+// Every shard is locked during the operation
+func (st *ShardedTree[K, V]) Extract(lo, hi Bound[K]) (*Tree[K, V], error) {
+	if err := validRange(lo, hi); err != nil {
+		return nil, err
+	}
+	var keys []K
+	var values []V
+	st.writeShards(allShards[K, V], func(shards []*shard[K, V]) {
+		keys, values = make([]K, 0), make([]V, 0)
+		for _, s := range shards {
+			removed := s.tree.extract(lo, hi)
+			if removed.RootNode != nil {
+				for _, node := range removed.RootNode.Print(0, 1) {
+					keys, values = append(keys, node.Key), append(values, node.Value)
+				}
+			}
+		}
+	})
+	return newTreeFromSorted(keys, values), nil
+}
+
+// Clone() returns a copy of the tree, with the same shards, like Tree.Clone()
+func (st *ShardedTree[K, V]) Clone() *ShardedTree[K, V] {
+	return st.CloneFunc(func(value V) V { return value })
+}
+
+// CloneFunc() acts like Clone() but copies each value with copyValue
+// Only the entries are copied : TTLs, observers and tracer are not
+func (st *ShardedTree[K, V]) CloneFunc(copyValue func(value V) V) *ShardedTree[K, V] {
+	shards := make([]*shard[K, V], 0)
+	st.eachShard(func(s *shard[K, V]) {
+		clone := &Tree[K, V]{core: mapCore(&s.tree.core, func(key K, value V) V {
+			return copyValue(value)
+		})}
+		shards = append(shards, &shard[K, V]{tree: clone, upper: s.upper, hasUpper: s.hasUpper})
+	})
+	clone := &ShardedTree[K, V]{maxShardSize: st.maxShardSize}
+	clone.shards.Store(&shards)
+	return clone
+}
+
+// Compute() atomically reads, modifies and writes the value of the key, like Tree.Compute()
+func (st *ShardedTree[K, V]) Compute(key K, fn func(old V, ok bool) (V, Op)) (value V, ok bool) {
+	s, expired := st.lockShard(key)
+	value, ok = s.tree.computeNode(key, fn)
+	st.unlockShard(s, expired)
+	return value, ok
+}
+
+// GetOrPut() returns the value of the key if present, otherwise it puts value, like Tree.GetOrPut()
+func (st *ShardedTree[K, V]) GetOrPut(key K, value V) (actual V, loaded bool) {
+	actual, _ = st.Compute(key, getOrPut(value, &loaded))
+	return actual, loaded
+}
+
+// PutIfAbsent() puts the key/value only if the key is absent and returns true if it was put
+func (st *ShardedTree[K, V]) PutIfAbsent(key K, value V) (put bool) {
+	st.Compute(key, putIfAbsent(value, &put))
+	return put
+}
+
+// Update() replaces the value of the key by fn(old) only if the key is present, like Tree.Update()
+func (st *ShardedTree[K, V]) Update(key K, fn func(old V) V) (value V, ok bool) {
+	return st.Compute(key, update(fn))
+}
+
+// CompareAndSwap() replaces the value of the key by value only if its current value is equal to old, like Tree.CompareAndSwap()
+func (st *ShardedTree[K, V]) CompareAndSwap(key K, old, value V, eq func(a, b V) bool) (swapped bool) {
+	st.Compute(key, compareAndSwap(old, value, eq, &swapped))
+	return swapped
+}
+
+// CompareAndDelete() deletes the key only if its current value is equal to old, like Tree.CompareAndDelete()
+func (st *ShardedTree[K, V]) CompareAndDelete(key K, old V, eq func(a, b V) bool) (deleted bool) {
+	st.Compute(key, compareAndDelete(old, eq, &deleted))
+	return deleted
+}
+
+// GetFromTo() returns an ordered slice of values for keys found between from and to (including bounds or not)
+func (st *ShardedTree[K, V]) GetFromTo(from, to K, boundsIncluded bool) (values []V) {
+	lo, hi := boundsOf(from, to, boundsIncluded)
+	st.ascend(lo, hi, func(node *Node[K, V]) bool {
+		values = append(values, node.Value)
+		return true
+	})
+	return values
+}
+
+// Floor() returns the entry of the biggest key of the tree smaller or equal to key (ok is false if there is none)
+func (st *ShardedTree[K, V]) Floor(key K) (floor K, value V, ok bool) {
+	return st.search(key, -1, func(root *Node[K, V]) *Node[K, V] {
+		return root.floor(key)
+	})
+}
+
+// Ceiling() returns the entry of the smallest key of the tree bigger or equal to key (ok is false if there is none)
+func (st *ShardedTree[K, V]) Ceiling(key K) (ceiling K, value V, ok bool) {
+	return st.search(key, 1, func(root *Node[K, V]) *Node[K, V] {
+		return root.ceiling(key)
+	})
+}
+
+// Print() returns the ordered nodes of the shards
+// depth represents the depth in which print the elements, counted in each shard (0 for all depths)
+func (st *ShardedTree[K, V]) Print(depth uint) (nodes []*Node[K, V]) {
+	st.eachShard(func(s *shard[K, V]) {
+		nodes = append(nodes, s.tree.print(depth)...)
+	})
+	return nodes
+}
+
+// PrintKeys() act like Print but returns only the ordered array if keys in the tree
+func (st *ShardedTree[K, V]) PrintKeys(depth uint) (keys []K) {
+	return nodeKeys(st.Print(depth))
+}
+
+// PrintValues() act like Print but returns only the ordered array of values in the tree
+func (st *ShardedTree[K, V]) PrintValues(depth uint) (values []V) {
+	return nodeValues(st.Print(depth))
+}
+
+// Stats() returns the counters of the shards added together, like Tree.Stats()
+// Height is the biggest height of the shards, and DepthHistogram counts the nodes by their depth in their shard
+func (st *ShardedTree[K, V]) Stats() (stats Stats) {
+	st.eachShard(func(s *shard[K, V]) {
+		shardStats := s.tree.stats()
+		stats.Size += shardStats.Size
+		if shardStats.Height > stats.Height {
+			stats.Height = shardStats.Height
+		}
+		for len(stats.DepthHistogram) < len(shardStats.DepthHistogram) {
+			stats.DepthHistogram = append(stats.DepthHistogram, 0)
+		}
+		for i, nodes := range shardStats.DepthHistogram {
+			stats.DepthHistogram[i] += nodes
+		}
+		stats.LeftRotations += shardStats.LeftRotations
+		stats.RightRotations += shardStats.RightRotations
+		stats.DoubleRotations += shardStats.DoubleRotations
+		stats.BalancedClimbs += shardStats.BalancedClimbs
+
+		stats.LockWaits += s.tree.waits.lockWaits.Load()
+		stats.LockWaitTime += time.Duration(s.tree.waits.lockWaitNanos.Load())
+		if wait := time.Duration(s.tree.waits.maxLockWait.Load()); wait > stats.MaxLockWait {
+			stats.MaxLockWait = wait
+		}
+	})
+	stats.MinHeight = bits.Len(uint(stats.Size))
+	return stats
+}
+
+// Encode() serialize the tree in gob format, as one Tree : the file can be read by Decode() or by DecodeSharded()
+// The expired entries are left out and the deadlines of the others are encoded, like Tree.Encode()
+// All the shards are read-locked while they are copied
+func (st *ShardedTree[K, V]) Encode(output string) error {
+	keys, values := make([]K, 0), make([]V, 0)
+	whole := core[K, V]{}
+	st.eachShard(func(s *shard[K, V]) {
+		for _, node := range s.tree.print(0) {
+			keys, values = append(keys, node.Key), append(values, node.Value)
+		}
+		for key, deadline := range s.tree.expiration.deadlines {
+			whole.setDeadline(key, deadline)
+		}
+	})
+	whole.RootNode = newBalancedNode(keys, values, nil)
+	return whole.encode(output)
+}
+
+// DecodeSharded() deserialize a tree from an input file written by Encode(), in a ShardedTree split at the boundaries
+// The boundaries are not encoded : the tree can be decoded with other ones, like NewShardedTree()
+func DecodeSharded[K Ordered, V any](input string, boundaries []K, maxShardSize int) (*ShardedTree[K, V], error) {
+	tree, err := Decode[K, V](input)
+	if err != nil {
+		return nil, err
+	}
+	st := NewShardedTree[K, V](boundaries, maxShardSize)
+
+	//Print() removes the expired entries, so every remaining deadline belongs to a node
+	nodes := tree.Print(0)
+	for _, s := range *st.shards.Load() {
+		keys, values := make([]K, 0), make([]V, 0)
+		for len(nodes) > 0 && (!s.hasUpper || nodes[0].Key < s.upper) {
+			keys, values = append(keys, nodes[0].Key), append(values, nodes[0].Value)
+			nodes = nodes[1:]
+		}
+		s.tree = newTreeFromSorted(keys, values)
+		for _, key := range keys {
+			if deadline, ok := tree.expiration.deadlines[key]; ok {
+				s.tree.setDeadline(key, deadline)
+			}
+		}
+	}
+	return st, nil
+}
+
+// Ascend() calls fn for each key/value of the tree, in order, until fn returns false
+// Each shard is read-locked while it is walked, so fn must not modify the tree
+func (st *ShardedTree[K, V]) Ascend(fn func(key K, value V) bool) {
+	st.ascend(Unbounded[K](), Unbounded[K](), func(node *Node[K, V]) bool {
+		return fn(node.Key, node.Value)
+	})
+}
+
+// AscendFromTo() acts like Ascend() for the keys between from and to (including bounds or not)
+func (st *ShardedTree[K, V]) AscendFromTo(from, to K, boundsIncluded bool, fn func(key K, value V) bool) {
+	lo, hi := boundsOf(from, to, boundsIncluded)
+	st.ascend(lo, hi, func(node *Node[K, V]) bool {
+		return fn(node.Key, node.Value)
+	})
+}
+
+// ascend() walks the shards overlapping the range between the bounds lo and hi, in order (see Node.ascend())
+func (st *ShardedTree[K, V]) ascend(lo, hi Bound[K], fn func(node *Node[K, V]) bool) {
+	for {
+		shards := *st.shards.Load()
+		s := shards[0]
+		if lo.kind != boundUnbounded {
+			s = shards[route(shards, lo.key)]
+		}
+
+		if !st.rlockShard(s) { //the shard has been split meanwhile : route again
+			continue
+		}
+		stopped := false
+		if s.tree.RootNode != nil {
+			stopped = !s.tree.RootNode.ascend(lo, hi, fn)
+		}
+		s.tree.runlock()
+
+		if stopped || !s.hasUpper || !hi.admitsBelow(s.upper) {
+			return
+		}
+		//go on with the next shard, which starts at the upper bound of this one
+		lo = Inclusive(s.upper)
+	}
+}
+
+// search() calls find on the root node of the shard of key, then of the previous shards (step -1) or of the next ones (step 1),
+// each of them read-locked, until it returns a node
+func (st *ShardedTree[K, V]) search(key K, step int, find func(root *Node[K, V]) *Node[K, V]) (K, V, bool) {
+	for {
+		shards := *st.shards.Load()
+		split := false
+		for i := route(shards, key); i >= 0 && i < len(shards); i += step {
+			if !st.rlockShard(shards[i]) {
+				split = true
+				break
+			}
+			found, value, ok := entryOf(find(shards[i].tree.RootNode))
+			shards[i].tree.runlock()
+			if ok {
+				return found, value, true
+			}
+		}
+		if !split {
+			return entryOf[K, V](nil)
+		}
+		//a shard has been split meanwhile : start again
+	}
+}
+
+// eachShard() calls fn for each shard, read-locked, starting again if a shard is split meanwhile
+func (st *ShardedTree[K, V]) eachShard(fn func(s *shard[K, V])) {
+	for {
+		shards := *st.shards.Load()
+		locked := 0
+		for _, s := range shards {
+			if !st.rlockShard(s) {
+				break
+			}
+			locked++
+		}
+		if locked == len(shards) {
+			for _, s := range shards {
+				fn(s)
+			}
+		}
+		for _, s := range shards[:locked] {
+			s.tree.runlock()
+		}
+		if locked == len(shards) {
+			return
+		}
+	}
+}
+
+// lockShard() returns the shard of the key locked for writing, and its expired entries, which it removed
+func (st *ShardedTree[K, V]) lockShard(key K) (*shard[K, V], []*Node[K, V]) {
+	for {
+		shards := *st.shards.Load()
+		s := shards[route(shards, key)]
+		s.tree.lock()
+		if !s.retired {
+			return s, s.tree.popExpired()
+		}
+		//the shard has been split meanwhile : route again
+		s.tree.unlock()
+	}
+}
+
+// unlockShard() unlocks a shard locked by lockShard(), notifies its changes and splits it if needed
+func (st *ShardedTree[K, V]) unlockShard(s *shard[K, V], expired []*Node[K, V]) {
+	changes := s.tree.settle(expired)
+	size := s.tree.count
+	s.tree.unlock()
+
+	changes.notify()
+	st.splitIfNeeded(s, size)
+}
+
+// writeShards() locks for writing the shards chosen by pick among the current ones, in order, and calls fn with them
+// It starts again if a shard is split meanwhile, so fn is called once, with shards that are not retired
+// Their changes are notified once they are unlocked. It returns the number of expired entries removed
+func (st *ShardedTree[K, V]) writeShards(pick func(shards []*shard[K, V]) []*shard[K, V], fn func(shards []*shard[K, V])) int {
+	for {
+		shards := pick(*st.shards.Load())
+		locked := 0
+		for _, s := range shards {
+			s.tree.lock()
+			if s.retired {
+				s.tree.unlock()
+				break
+			}
+			locked++
+		}
+		if locked < len(shards) {
+			for _, s := range shards[:locked] {
+				s.tree.unlock()
+			}
+			continue
+		}
+
+		expired := make([][]*Node[K, V], len(shards))
+		removed := 0
+		for i, s := range shards {
+			expired[i] = s.tree.popExpired()
+			removed += len(expired[i])
+		}
+		fn(shards)
+		changes, sizes := make([]changes[K, V], len(shards)), make([]int, len(shards))
+		for i, s := range shards {
+			changes[i], sizes[i] = s.tree.settle(expired[i]), s.tree.count
+			s.tree.unlock()
+		}
+		for i, s := range shards {
+			changes[i].notify()
+			st.splitIfNeeded(s, sizes[i])
+		}
+		return removed
+	}
+}
+
+// allShards() picks every shard for writeShards()
+func allShards[K Ordered, V any](shards []*shard[K, V]) []*shard[K, V] {
+	return shards
+}
+
+// rlockShardOf() returns the shard of the key, read-locked, without expired entries
+func (st *ShardedTree[K, V]) rlockShardOf(key K) *shard[K, V] {
+	for {
+		shards := *st.shards.Load()
+		if s := shards[route(shards, key)]; st.rlockShard(s) {
+			return s
+		}
+	}
+}
+
+// configure() calls fn with the tree of each shard, locked, while no shard is split :
+// the new shards take the configuration of the split one (clock, callbacks, observers and tracer)
+func (st *ShardedTree[K, V]) configure(fn func(t *Tree[K, V])) {
+	st.reshardMutex.Lock()
+	defer st.reshardMutex.Unlock()
+	for _, s := range *st.shards.Load() {
+		s.tree.lock()
+		fn(s.tree)
+		s.tree.unlock()
+	}
+}
+
+// observe() returns the observers shared by the shards, created on the first registration
+func (st *ShardedTree[K, V]) observe() (o *observers[K, V]) {
+	st.configure(func(t *Tree[K, V]) {
+		if o == nil {
+			o = t.observe()
+		}
+		t.observers = o
+	})
+	return o
+}
+
+// rlockShard() read-locks the shard, once its expired entries are removed
+// It returns false, with the shard unlocked, if the shard has been split meanwhile
+func (st *ShardedTree[K, V]) rlockShard(s *shard[K, V]) bool {
+	for {
+		s.tree.rlock()
+		if s.retired {
+			s.tree.runlock()
+			return false
+		}
+		if !s.tree.expired() {
+			return true
+		}
+		s.tree.runlock()
+		s.tree.RemoveExpired()
+	}
+}
+
+// splitIfNeeded() splits the shard in two halves if it holds more than maxShardSize entries
+// The new shards are published and the old one is retired, while it is locked
+func (st *ShardedTree[K, V]) splitIfNeeded(s *shard[K, V], size int) {
+	if st.maxShardSize <= 0 || size <= st.maxShardSize {
+		return
+	}
+	st.reshardMutex.Lock()
+	defer st.reshardMutex.Unlock()
+	s.tree.lock()
+	defer s.tree.unlock()
+
+	if s.retired || s.tree.count <= st.maxShardSize {
+		return
+	}
+
+	nodes := s.tree.RootNode.Print(0, 1)
+	keys, values := make([]K, len(nodes)), make([]V, len(nodes))
+	for i, node := range nodes {
+		keys[i], values[i] = node.Key, node.Value
+	}
+	middle := len(nodes) / 2
+	lower := &shard[K, V]{tree: newTreeFromSorted(keys[:middle], values[:middle]), upper: keys[middle], hasUpper: true}
+	higher := &shard[K, V]{tree: newTreeFromSorted(keys[middle:], values[middle:]), upper: s.upper, hasUpper: s.hasUpper}
+	//the new shards keep the configuration and the deadlines of the split one
+	for _, t := range []*Tree[K, V]{lower.tree, higher.tree} {
+		t.expiration.now, t.expiration.onExpire = s.tree.expiration.now, s.tree.expiration.onExpire
+		t.observers, t.probe.tracer = s.tree.observers, s.tree.probe.tracer
+	}
+	for key, deadline := range s.tree.expiration.deadlines {
+		if key < lower.upper {
+			lower.tree.setDeadline(key, deadline)
+		} else {
+			higher.tree.setDeadline(key, deadline)
+		}
+	}
+
+	old := *st.shards.Load()
+	shards := make([]*shard[K, V], 0, len(old)+1)
+	for _, other := range old {
+		if other == s {
+			shards = append(shards, lower, higher)
+		} else {
+			shards = append(shards, other)
+		}
+	}
+	st.shards.Store(&shards)
+	s.retired = true
+}
+
+// route() returns the index of the shard of the key
+func route[K Ordered, V any](shards []*shard[K, V], key K) int {
+	return sort.Search(len(shards), func(i int) bool {
+		return !shards[i].hasUpper || key < shards[i].upper
+	})
+}
+
+// newTreeFromSorted() returns a Tree holding the ordered keys and values, built in O(n)
+func newTreeFromSorted[K Ordered, V any](keys []K, values []V) *Tree[K, V] {
+	return &Tree[K, V]{core: core[K, V]{RootNode: newBalancedNode(keys, values, nil), count: len(keys)}}
+}
@@ -0,0 +1,361 @@
+package avlgo
+
+import (
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShardedTree(t *testing.T) {
+	tree := NewShardedTree[int, int]([]int{100, 50, 50}, 0)
+	if tree.Shards() != 3 {
+		t.Errorf("Tree has %d shards, want 3", tree.Shards())
+	}
+	for i := 0; i < 150; i += 10 {
+		tree.PutOne(i, i)
+	}
+	if tree.Size() != 15 {
+		t.Errorf("Tree size is %d, want 15", tree.Size())
+	}
+	if value, ok := tree.Get(50); !ok || value != 50 {
+		t.Errorf("Get returns %d, %v, want 50, true", value, ok)
+	}
+
+	values := tree.GetFromTo(30, 110, true)
+	wanted := []int{30, 40, 50, 60, 70, 80, 90, 100, 110}
+	if !reflect.DeepEqual(values, wanted) {
+		t.Errorf("values is %v, want %v", values, wanted)
+	}
+	values = tree.GetFromTo(40, 100, false)
+	wanted = []int{50, 60, 70, 80, 90}
+	if !reflect.DeepEqual(values, wanted) {
+		t.Errorf("values is %v, want %v", values, wanted)
+	}
+
+	keys := make([]int, 0)
+	tree.Ascend(func(key, value int) bool {
+		keys = append(keys, key)
+		return key < 60
+	})
+	wanted = []int{0, 10, 20, 30, 40, 50, 60}
+	if !reflect.DeepEqual(keys, wanted) {
+		t.Errorf("keys is %v, want %v", keys, wanted)
+	}
+
+	if deleted := tree.Delete(50, 55, 100); deleted != 2 {
+		t.Errorf("Deleted nodes is %d, want 2", deleted)
+	}
+	if tree.Size() != 13 {
+		t.Errorf("Tree size is %d, want 13", tree.Size())
+	}
+}
+
+func TestShardedTreeFloorCeilingAndPrint(t *testing.T) {
+	tree := NewShardedTree[int, int]([]int{100, 200, 300}, 0)
+	for _, key := range []int{10, 50, 250, 320} {
+		tree.PutOne(key, key*10)
+	}
+
+	//the floor and the ceiling may be several shards away
+	if floor, value, ok := tree.Floor(240); !ok || floor != 50 || value != 500 {
+		t.Errorf("Floor(240) returns %d, %d, %v, want 50, 500, true", floor, value, ok)
+	}
+	if ceiling, _, ok := tree.Ceiling(60); !ok || ceiling != 250 {
+		t.Errorf("Ceiling(60) returns %d, %v, want 250, true", ceiling, ok)
+	}
+	if floor, _, ok := tree.Floor(250); !ok || floor != 250 {
+		t.Errorf("Floor(250) returns %d, %v, want 250, true", floor, ok)
+	}
+	if _, _, ok := tree.Floor(5); ok {
+		t.Errorf("Floor(5) shouldn't find anything")
+	}
+	if _, _, ok := tree.Ceiling(400); ok {
+		t.Errorf("Ceiling(400) shouldn't find anything")
+	}
+
+	if keys, want := tree.PrintKeys(0), []int{10, 50, 250, 320}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys is %v, want %v", keys, want)
+	}
+	if values, want := tree.PrintValues(0), []int{100, 500, 2500, 3200}; !reflect.DeepEqual(values, want) {
+		t.Errorf("values is %v, want %v", values, want)
+	}
+	//the depth is counted in each shard
+	if keys, want := tree.PrintKeys(2), []int{50}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys at depth 2 is %v, want %v", keys, want)
+	}
+
+	stats := tree.Stats()
+	if stats.Size != 4 || stats.Height != 2 || !reflect.DeepEqual(stats.DepthHistogram, []int{3, 1}) {
+		t.Errorf("stats are %+v, want a size of 4, a height of 2 and a histogram of [3 1]", stats)
+	}
+}
+
+func TestShardedTreeTTL(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	tree := NewShardedTree[int, int](nil, 4)
+	tree.SetClock(clock.Now)
+
+	for i := 0; i < 10; i++ {
+		if i%2 == 0 {
+			tree.PutWithTTL(i, i, time.Second)
+		} else {
+			tree.PutOne(i, i)
+		}
+	}
+	if tree.Shards() < 2 {
+		t.Fatalf("Tree has %d shards, want at least 2", tree.Shards())
+	}
+	if remaining, ok := tree.TTL(8); !ok || remaining != time.Second {
+		t.Errorf("TTL(8) returns %v, %v, want 1s, true", remaining, ok)
+	}
+	tree.PutOne(2, 20) //2 doesn't expire anymore
+
+	clock.Advance(time.Second)
+	if _, ok := tree.Get(4); ok {
+		t.Errorf("Get shouldn't find the expired key 4")
+	}
+	if keys, want := tree.PrintKeys(0), []int{1, 2, 3, 5, 7, 9}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys is %v, want %v", keys, want)
+	}
+	if tree.Size() != 6 {
+		t.Errorf("Tree size is %d, want 6", tree.Size())
+	}
+	if floor, _, ok := tree.Floor(8); !ok || floor != 7 {
+		t.Errorf("Floor(8) returns %d, %v, want 7, true", floor, ok)
+	}
+}
+
+func TestShardedTreeEncodeAndDecode(t *testing.T) {
+	tree := NewShardedTree[int, string]([]int{10}, 0)
+	tree.PutOne(1, "a")
+	tree.PutOne(15, "b")
+	tree.PutWithTTL(20, "c", time.Hour)
+	tree.PutWithTTL(5, "d", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	file := filepath.Join(t.TempDir(), "tree.gob")
+	if err := tree.Encode(file); err != nil {
+		t.Fatalf("Encode() fails : %s", err)
+	}
+
+	//the file holds one Tree, which can be decoded with other boundaries
+	decoded, err := DecodeSharded[int, string](file, []int{2, 16}, 0)
+	if err != nil {
+		t.Fatalf("DecodeSharded() fails : %s", err)
+	}
+	if decoded.Shards() != 3 {
+		t.Errorf("Tree has %d shards, want 3", decoded.Shards())
+	}
+	if keys, want := decoded.PrintKeys(0), []int{1, 15, 20}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys is %v, want %v", keys, want)
+	}
+	if remaining, ok := decoded.TTL(20); !ok || remaining <= 0 || remaining > time.Hour {
+		t.Errorf("TTL(20) returns %v, %v, want at most 1h, true", remaining, ok)
+	}
+
+	whole, err := Decode[int, string](file)
+	if err != nil {
+		t.Fatalf("Decode() fails : %s", err)
+	}
+	if values, want := whole.PrintValues(0), []string{"a", "b", "c"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("values is %v, want %v", values, want)
+	}
+}
+
+func TestShardedTreeFromSamples(t *testing.T) {
+	samples := make([]int, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		samples = append(samples, i)
+	}
+	tree := NewShardedTreeFromSamples[int, int](samples, 4, 0)
+	if tree.Shards() != 4 {
+		t.Errorf("Tree has %d shards, want 4", tree.Shards())
+	}
+	shards := *tree.shards.Load()
+	for i, boundary := range []int{250, 500, 750} {
+		if shards[i].upper != boundary {
+			t.Errorf("boundary %d is %d, want %d", i, shards[i].upper, boundary)
+		}
+	}
+}
+
+func TestShardedTreeResharding(t *testing.T) {
+	tree := NewShardedTree[int, int](nil, 16)
+	m := newModel()
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		key := random.Intn(500)
+		switch random.Intn(3) {
+		case 0, 1:
+			tree.PutOne(key, i)
+			m.put(key, i)
+		case 2:
+			if deleted, want := tree.Delete(key), m.delete(key); deleted != want {
+				t.Fatalf("Delete(%d) returns %d, want %d", key, deleted, want)
+			}
+		}
+	}
+	if tree.Shards() < 500/16 {
+		t.Errorf("Tree has %d shards, want at least %d", tree.Shards(), 500/16)
+	}
+	if tree.Size() != len(m.keys) {
+		t.Errorf("Tree size is %d, want %d", tree.Size(), len(m.keys))
+	}
+	if values, want := tree.GetFromTo(-1, 500, true), m.orderedValues(); !reflect.DeepEqual(values, want) {
+		t.Errorf("values is %v, want %v", values, want)
+	}
+	for _, s := range *tree.shards.Load() {
+		if s.tree.RootNode != nil {
+			if _, ok := s.tree.RootNode.isValid(); !ok {
+				t.Errorf("a shard is not a valid AVL tree")
+			}
+		}
+	}
+}
+
+func TestShardedTreeConcurrentWrites(t *testing.T) {
+	tree := NewShardedTree[int, int](nil, 64)
+	const writers, items = 8, 1000
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < items; i++ {
+				tree.PutOne(i*writers+w, i)
+				tree.Compute(-1, func(old int, ok bool) (int, Op) {
+					return old + 1, OpPut
+				})
+				tree.GetFromTo(i, i+100, true)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if tree.Size() != writers*items+1 {
+		t.Errorf("Tree size is %d, want %d", tree.Size(), writers*items+1)
+	}
+	if value, _ := tree.Get(-1); value != writers*items {
+		t.Errorf("counter is %d, want %d", value, writers*items)
+	}
+	previous, count := -2, 0
+	tree.Ascend(func(key, value int) bool {
+		if key <= previous {
+			t.Errorf("keys are not ordered : %d after %d", key, previous)
+		}
+		previous = key
+		count++
+		return true
+	})
+	if count != writers*items+1 {
+		t.Errorf("Ascend walks %d keys, want %d", count, writers*items+1)
+	}
+}
+
+func TestShardedTreeObservers(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	tree := NewShardedTree[int, int]([]int{100}, 4)
+	tree.SetClock(clock.Now)
+
+	var mutex sync.Mutex
+	puts, deletes, expired := make([]int, 0), make([]int, 0), make([]int, 0)
+	tree.OnPut(func(key, value int) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		puts = append(puts, key)
+	})
+	tree.OnDelete(func(key, value int) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		deletes = append(deletes, key)
+	})
+	tree.OnExpire(func(key, value int) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		expired = append(expired, key)
+	})
+	events, cancel := tree.Watch(150, 200, WatchOptions{Buffer: 10})
+	defer cancel()
+
+	for i := 0; i < 10; i++ {
+		tree.PutOne(i, i) //the first shard is split
+	}
+	tree.PutWithTTL(150, 150, time.Second)
+	tree.Delete(3)
+	if tree.Shards() < 3 {
+		t.Fatalf("Tree has %d shards, want at least 3", tree.Shards())
+	}
+	tree.PutOne(1, 10) //in a new shard
+
+	clock.Advance(time.Second)
+	if removed := tree.RemoveExpired(); removed != 1 {
+		t.Errorf("RemoveExpired returns %d, want 1", removed)
+	}
+
+	if want := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 150, 1}; !reflect.DeepEqual(puts, want) {
+		t.Errorf("puts is %v, want %v", puts, want)
+	}
+	if want := []int{3, 150}; !reflect.DeepEqual(deletes, want) {
+		t.Errorf("deletes is %v, want %v", deletes, want)
+	}
+	if want := []int{150}; !reflect.DeepEqual(expired, want) {
+		t.Errorf("expired is %v, want %v", expired, want)
+	}
+	for _, want := range []EventType{EventPut, EventDelete} {
+		if event := <-events; event.Type != want || event.Key != 150 {
+			t.Errorf("event is %v, want a %v of 150", event, want)
+		}
+	}
+}
+
+func TestShardedTreeBulkOperations(t *testing.T) {
+	tree := NewShardedTree[int, int]([]int{10, 20}, 0)
+	put, deleted, err := tree.Batch(
+		BatchOp[int, int]{Key: 5, Value: 5},
+		BatchOp[int, int]{Key: 15, Value: 15},
+		BatchOp[int, int]{Key: 25, Value: 25},
+		BatchOp[int, int]{Key: 12, Value: 12},
+		BatchOp[int, int]{Key: 5, Delete: true},
+	)
+	if err != nil || put != 4 || deleted != 1 {
+		t.Errorf("Batch returns %d, %d, %v, want 4, 1, nil", put, deleted, err)
+	}
+	for i := 0; i < 30; i += 3 {
+		tree.PutOne(i, i)
+	}
+
+	clone := tree.CloneFunc(func(value int) int { return -value })
+	if clone.Shards() != tree.Shards() || clone.Size() != tree.Size() {
+		t.Errorf("clone has %d shards and %d keys, want %d and %d", clone.Shards(), clone.Size(), tree.Shards(), tree.Size())
+	}
+
+	extracted, err := tree.Extract(Inclusive(9), Exclusive(21))
+	if err != nil {
+		t.Fatalf("Extract returns %v", err)
+	}
+	if keys, want := extracted.PrintKeys(0), []int{9, 12, 15, 18}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("extracted keys is %v, want %v", keys, want)
+	}
+	if removed := tree.DeleteFunc(func(key, value int) bool { return key%2 == 1 }); removed != 4 {
+		t.Errorf("DeleteFunc returns %d, want 4", removed)
+	}
+	if keys, want := tree.PrintKeys(0), []int{0, 6, 24}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys is %v, want %v", keys, want)
+	}
+	if value, ok := clone.Get(15); !ok || value != -15 {
+		t.Errorf("clone.Get(15) returns %d, %v, want -15, true", value, ok)
+	}
+
+	entries, next, err := clone.GetFromToPage(0, 30, true, 4, "")
+	if err != nil || len(entries) != 4 || entries[3].Key != 9 {
+		t.Fatalf("GetFromToPage returns %v, %q, %v", entries, next, err)
+	}
+	entries, _, err = clone.GetFromToPage(0, 30, true, 4, next)
+	if err != nil || len(entries) != 4 || entries[0].Key != 12 {
+		t.Errorf("second page is %v, %v, want 4 entries from 12", entries, err)
+	}
+}
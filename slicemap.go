@@ -0,0 +1,115 @@
+package avlgo
+
+import (
+	"sort"
+	"sync"
+)
+
+// SliceMap is a sorted slice of keys, and the slice of their values, implementing SortedMap
+// A lookup is a binary search over contiguous memory and a walk reads the slices in order, but a put or a delete
+// shifts the following entries : it is the fastest SortedMap for small sizes (up to a few thousand entries)
+// or for maps which are mostly read
+// Like a Tree, it is safe for concurrent use : reads take a read lock, writes a lock
+type SliceMap[K Ordered, V any] struct {
+	rwMutex sync.RWMutex
+	keys    []K
+	values  []V
+}
+
+// NewSliceMap() returns an empty new SliceMap
+func NewSliceMap[K Ordered, V any]() *SliceMap[K, V] {
+	return &SliceMap[K, V]{}
+}
+
+// Size() returns the number of entries of the map
+func (m *SliceMap[K, V]) Size() int {
+	m.rwMutex.RLock()
+	defer m.rwMutex.RUnlock()
+	return len(m.keys)
+}
+
+// Get() returns the value present in the map for the key
+func (m *SliceMap[K, V]) Get(key K) (value V, ok bool) {
+	m.rwMutex.RLock()
+	defer m.rwMutex.RUnlock()
+	if i, found := m.find(key); found {
+		return m.values[i], true
+	}
+	return value, false
+}
+
+// GetFromTo() returns an ordered slice of values for keys found between from and to (including bounds or not)
+func (m *SliceMap[K, V]) GetFromTo(from, to K, boundsIncluded bool) (values []V) {
+	m.AscendFromTo(from, to, boundsIncluded, func(key K, value V) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+// Ascend() calls fn for each key/value of the map, in order, until fn returns false
+// The map is read-locked during the walk, so fn must not modify it
+func (m *SliceMap[K, V]) Ascend(fn func(key K, value V) bool) {
+	m.rwMutex.RLock()
+	defer m.rwMutex.RUnlock()
+	for i, key := range m.keys {
+		if !fn(key, m.values[i]) {
+			return
+		}
+	}
+}
+
+// AscendFromTo() acts like Ascend() for the keys between from and to (including bounds or not)
+func (m *SliceMap[K, V]) AscendFromTo(from, to K, boundsIncluded bool, fn func(key K, value V) bool) {
+	m.rwMutex.RLock()
+	defer m.rwMutex.RUnlock()
+
+	lo, hi := boundsOf(from, to, boundsIncluded)
+	i, _ := m.find(from)
+	for ; i < len(m.keys) && hi.admitsBelow(m.keys[i]); i++ {
+		if lo.admitsAbove(m.keys[i]) && !fn(m.keys[i], m.values[i]) {
+			return
+		}
+	}
+}
+
+// PutOne() adds one element in the map, replacing the value if the key is already present
+// A NaN key is not put : it returns false
+func (m *SliceMap[K, V]) PutOne(key K, value V) bool {
+	if isNaN(key) {
+		return false
+	}
+	m.rwMutex.Lock()
+	defer m.rwMutex.Unlock()
+
+	i, found := m.find(key)
+	if found {
+		m.values[i] = value
+		return true
+	}
+	m.keys = insertAt(m.keys, i, key)
+	m.values = insertAt(m.values, i, value)
+	return true
+}
+
+// Delete() removes the entries of the passed keys and returns the number of entries deleted
+func (m *SliceMap[K, V]) Delete(keys ...K) int {
+	m.rwMutex.Lock()
+	defer m.rwMutex.Unlock()
+
+	deleted := 0
+	for _, k := range keys {
+		if i, found := m.find(k); found {
+			m.keys = removeAt(m.keys, i)
+			m.values = removeAt(m.values, i)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// find() returns the index of the first key bigger or equal to key, and true if it is equal
+func (m *SliceMap[K, V]) find(key K) (int, bool) {
+	i := sort.Search(len(m.keys), func(i int) bool { return m.keys[i] >= key })
+	return i, i < len(m.keys) && m.keys[i] == key
+}
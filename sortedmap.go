@@ -0,0 +1,38 @@
+package avlgo
+
+// SortedMap is an ordered key/value map : the methods of a Tree which code can be written against
+// to swap the implementation for the workload without changing the call sites
+//
+//   - Tree (NewTree()) : an AVL tree, the most strictly balanced, for read-heavy workloads
+//   - UnsyncTree (NewUnsyncTree()) : the same AVL tree without any locking, for one goroutine
+//   - RBTree (NewRBTree()) : a red-black tree, rotating less on writes
+//   - BTree (NewBTree()) : a B-tree, fewer and larger nodes, for large maps and long ordered walks
+//   - SliceMap (NewSliceMap()) : sorted slices, for small or mostly read maps
+//   - ShardedTree (NewShardedTree()) : a tree per key range, for concurrent writers
+//   - RCUTree (NewRCUTree()) : an AVL tree whose readers never lock
+type SortedMap[K Ordered, V any] interface {
+	//Get() returns the value present in the map for the key
+	Get(key K) (value V, ok bool)
+	//PutOne() adds the key/value, replacing the value if the key is already present
+	PutOne(key K, value V) bool
+	//Delete() removes the entries of the keys and returns the number of entries deleted
+	Delete(keys ...K) int
+	//GetFromTo() returns the ordered values for the keys between from and to (including bounds or not)
+	GetFromTo(from, to K, boundsIncluded bool) []V
+	//Size() returns the number of entries
+	Size() int
+	//Ascend() calls fn for each key/value, in order, until fn returns false
+	Ascend(fn func(key K, value V) bool)
+	//AscendFromTo() acts like Ascend() for the keys between from and to (including bounds or not)
+	AscendFromTo(from, to K, boundsIncluded bool, fn func(key K, value V) bool)
+}
+
+var (
+	_ SortedMap[int, int] = (*Tree[int, int])(nil)
+	_ SortedMap[int, int] = (*UnsyncTree[int, int])(nil)
+	_ SortedMap[int, int] = (*RBTree[int, int])(nil)
+	_ SortedMap[int, int] = (*BTree[int, int])(nil)
+	_ SortedMap[int, int] = (*SliceMap[int, int])(nil)
+	_ SortedMap[int, int] = (*ShardedTree[int, int])(nil)
+	_ SortedMap[int, int] = (*RCUTree[int, int])(nil)
+)
@@ -0,0 +1,226 @@
+package avlgo
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// sortedMaps are the SortedMap implementations checked by the conformance suite and compared by the benchmarks
+var sortedMaps = []struct {
+	name string
+	new  func() SortedMap[int, int]
+}{
+	{"Tree", func() SortedMap[int, int] { return NewTree[int, int]() }},
+	{"UnsyncTree", func() SortedMap[int, int] { return NewUnsyncTree[int, int]() }},
+	{"RBTree", func() SortedMap[int, int] { return NewRBTree[int, int]() }},
+	{"BTree2", func() SortedMap[int, int] { return NewBTree[int, int](2) }},
+	{"BTree", func() SortedMap[int, int] { return NewBTree[int, int](0) }},
+	{"SliceMap", func() SortedMap[int, int] { return NewSliceMap[int, int]() }},
+	{"ShardedTree", func() SortedMap[int, int] { return NewShardedTree[int, int]([]int{-100, 0, 100}, 64) }},
+	{"RCUTree", func() SortedMap[int, int] { return NewRCUTree[int, int]() }},
+}
+
+// validator is implemented by the maps which can check their internal invariants
+type validator interface {
+	isValid() bool
+}
+
+// checkSortedMap() compares the whole contents of the map with the model
+func checkSortedMap(t *testing.T, m SortedMap[int, int], ref *model) {
+	t.Helper()
+	if m.Size() != len(ref.keys) {
+		t.Fatalf("size is %d, want %d", m.Size(), len(ref.keys))
+	}
+	var keys, values []int
+	m.Ascend(func(key, value int) bool {
+		keys = append(keys, key)
+		values = append(values, value)
+		return true
+	})
+	if len(keys) != 0 || len(ref.keys) != 0 {
+		if !reflect.DeepEqual(keys, ref.keys) || !reflect.DeepEqual(values, ref.orderedValues()) {
+			t.Fatalf("entries are %v, %v, want %v, %v", keys, values, ref.keys, ref.orderedValues())
+		}
+	}
+	if v, ok := m.(validator); ok && !v.isValid() {
+		t.Fatalf("the invariants of the map are broken")
+	}
+}
+
+func TestSortedMapConformance(t *testing.T) {
+	for _, impl := range sortedMaps {
+		t.Run(impl.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(47))
+			m, ref := impl.new(), newModel()
+
+			for i := 0; i < 3000; i++ {
+				key := r.Intn(400) - 200
+				switch op := r.Intn(10); {
+				case op < 5:
+					if !m.PutOne(key, i) {
+						t.Fatalf("PutOne(%d) returns false", key)
+					}
+					ref.put(key, i)
+				case op < 7:
+					other := r.Intn(400) - 200
+					want := ref.delete(key)
+					if other != key {
+						want += ref.delete(other)
+					}
+					if deleted := m.Delete(key, other); deleted != want {
+						t.Fatalf("Delete(%d, %d) returns %d, want %d", key, other, deleted, want)
+					}
+				case op < 9:
+					value, ok := m.Get(key)
+					want, wantOK := ref.values[key]
+					if value != want || ok != wantOK {
+						t.Fatalf("Get(%d) returns %d, %v, want %d, %v", key, value, ok, want, wantOK)
+					}
+				default:
+					to := key + r.Intn(60)
+					included := r.Intn(2) == 0
+					if values, want := m.GetFromTo(key, to, included), ref.fromTo(key, to, included); len(values) != len(want) || (len(want) > 0 && !reflect.DeepEqual(values, want)) {
+						t.Fatalf("GetFromTo(%d, %d, %v) returns %v, want %v", key, to, included, values, want)
+					}
+				}
+				if i%100 == 0 {
+					checkSortedMap(t, m, ref)
+				}
+			}
+			checkSortedMap(t, m, ref)
+
+			//the walks stop when fn returns false
+			var walked []int
+			m.AscendFromTo(-50, 50, true, func(key, value int) bool {
+				walked = append(walked, key)
+				return len(walked) < 3
+			})
+			want := ref.keys[sort.SearchInts(ref.keys, -50):]
+			if len(want) > 3 {
+				want = want[:3]
+			}
+			if !reflect.DeepEqual(walked, want) {
+				t.Errorf("AscendFromTo walks %v, want %v", walked, want)
+			}
+			calls := 0
+			m.Ascend(func(key, value int) bool {
+				calls++
+				return false
+			})
+			if calls != 1 {
+				t.Errorf("Ascend calls fn %d times after it returned false, want 1", calls)
+			}
+
+			//emptying the map
+			if deleted := m.Delete(ref.keys...); deleted != len(ref.keys) {
+				t.Errorf("Delete of all the keys returns %d, want %d", deleted, len(ref.keys))
+			}
+			checkSortedMap(t, m, newModel())
+			if _, ok := m.Get(0); ok || m.Delete(0) != 0 || len(m.GetFromTo(-1000, 1000, true)) != 0 {
+				t.Errorf("an empty map should hold nothing")
+			}
+		})
+	}
+}
+
+func TestSortedMapBounds(t *testing.T) {
+	for _, impl := range sortedMaps {
+		t.Run(impl.name, func(t *testing.T) {
+			m := impl.new()
+			for _, k := range []int{10, 20, 30, 40} {
+				m.PutOne(k, k*10)
+			}
+			for _, test := range []struct {
+				from, to int
+				included bool
+				want     []int
+			}{
+				{10, 40, true, []int{100, 200, 300, 400}},
+				{10, 40, false, []int{200, 300}},
+				{20, 20, true, []int{200}},
+				{20, 20, false, nil},
+				{11, 39, false, []int{200, 300}},
+				{41, 90, true, nil},
+				{-90, 9, true, nil},
+			} {
+				if values := m.GetFromTo(test.from, test.to, test.included); len(values) != len(test.want) || (len(values) > 0 && !reflect.DeepEqual(values, test.want)) {
+					t.Errorf("GetFromTo(%d, %d, %v) returns %v, want %v", test.from, test.to, test.included, values, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBTreeDegrees(t *testing.T) {
+	for _, degree := range []int{2, 3, 4, 7} {
+		tree := NewBTree[int, int](degree)
+		ref := newModel()
+		for _, k := range rand.New(rand.NewSource(int64(degree))).Perm(500) {
+			tree.PutOne(k, k)
+			ref.put(k, k)
+		}
+		for k := 0; k < 500; k += 3 {
+			tree.Delete(k)
+			ref.delete(k)
+			if !tree.isValid() {
+				t.Fatalf("B-tree of degree %d is invalid after deleting %d", degree, k)
+			}
+		}
+		checkSortedMap(t, tree, ref)
+	}
+}
+
+// benchmarkSortedMaps() runs the benchmark on a map of each implementation filled with size shuffled keys
+func benchmarkSortedMaps(b *testing.B, size int, run func(b *testing.B, m SortedMap[int, int], keys []int)) {
+	keys := rand.New(rand.NewSource(1)).Perm(size)
+	for _, impl := range sortedMaps {
+		b.Run(fmt.Sprintf("%s/%d", impl.name, size), func(b *testing.B) {
+			m := impl.new()
+			for _, k := range keys {
+				m.PutOne(k, k)
+			}
+			b.ResetTimer()
+			run(b, m, keys)
+		})
+	}
+}
+
+func BenchmarkSortedMapGet(b *testing.B) {
+	for _, size := range []int{100, 100000} {
+		benchmarkSortedMaps(b, size, func(b *testing.B, m SortedMap[int, int], keys []int) {
+			for i := 0; i < b.N; i++ {
+				m.Get(keys[i%len(keys)])
+			}
+		})
+	}
+}
+
+func BenchmarkSortedMapPutDelete(b *testing.B) {
+	for _, size := range []int{100, 100000} {
+		benchmarkSortedMaps(b, size, func(b *testing.B, m SortedMap[int, int], keys []int) {
+			for i := 0; i < b.N; i++ {
+				k := keys[i%len(keys)]
+				m.Delete(k)
+				m.PutOne(k, i)
+			}
+		})
+	}
+}
+
+func BenchmarkSortedMapAscend(b *testing.B) {
+	for _, size := range []int{100, 100000} {
+		benchmarkSortedMaps(b, size, func(b *testing.B, m SortedMap[int, int], keys []int) {
+			for i := 0; i < b.N; i++ {
+				from := keys[i%len(keys)]
+				count := 0
+				m.AscendFromTo(from, from+100, true, func(key, value int) bool {
+					count++
+					return true
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package avlgo
+
+import (
+	"bufio"
+	"bytes"
+	"expvar"
+	"fmt"
+	"io"
+	"math/bits"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the shape of a Tree and of its counters, returned by Tree.Stats()
+type Stats struct {
+	Size      int //number of entries
+	Height    int //depth of the tree (0 for an empty tree)
+	MinHeight int //smallest possible height for Size entries (a perfectly balanced tree)
+	//DepthHistogram[i] is the number of nodes at depth i+1 (the root node is at depth 1)
+	DepthHistogram []int
+
+	LeftRotations   int64 //single rotations to the left
+	RightRotations  int64 //single rotations to the right
+	DoubleRotations int64 //right-left and left-right rotations
+	//BalancedClimbs is the number of times the rebalancing went on to the parent of an already balanced node
+	BalancedClimbs int64
+
+	LockWaits    int64         //number of locks which waited for another goroutine
+	LockWaitTime time.Duration //total time spent waiting for the lock
+	MaxLockWait  time.Duration //longest wait for the lock
+}
+
+// StatsReporter is anything reporting Stats, like a Tree
+type StatsReporter interface {
+	Stats() Stats
+}
+
+// rotationKind is a kind of rotation counted in the stats
+type rotationKind int
+
+const (
+	rotationLeft rotationKind = iota
+	rotationRight
+	rotationDouble
+)
+
+// treeStats holds the rotation counters of a tree, counted with the tree locked
+type treeStats struct {
+	rotations      [3]int64 //indexed by rotationKind
+	balancedClimbs int64
+}
+
+// rotated() counts a rotation
+func (s *treeStats) rotated(kind rotationKind) {
+	s.rotations[kind]++
+}
+
+// climbed() counts a climb past a balanced node
+func (s *treeStats) climbed() {
+	s.balancedClimbs++
+}
+
+// lockStats holds the lock wait counters of a Tree. Its methods are safe for concurrent use
+type lockStats struct {
+	lockWaits     atomic.Int64
+	lockWaitNanos atomic.Int64
+	maxLockWait   atomic.Int64
+}
+
+// waited() counts a wait for the lock
+func (s *lockStats) waited(wait time.Duration) {
+	s.lockWaits.Add(1)
+	s.lockWaitNanos.Add(int64(wait))
+	for {
+		max := s.maxLockWait.Load()
+		if int64(wait) <= max || s.maxLockWait.CompareAndSwap(max, int64(wait)) {
+			return
+		}
+	}
+}
+
+// Stats() returns the shape of the tree and its counters since its creation
+// The depth histogram walks the whole tree with the tree read-locked : it runs in O(n)
+func (t *Tree[K, V]) Stats() Stats {
+	t.rlockLive()
+	stats := t.stats()
+	t.runlock()
+
+	stats.LockWaits = t.waits.lockWaits.Load()
+	stats.LockWaitTime = time.Duration(t.waits.lockWaitNanos.Load())
+	stats.MaxLockWait = time.Duration(t.waits.maxLockWait.Load())
+	return stats
+}
+
+// Stats() returns the shape of the tree and its counters since its creation, like Tree.Stats()
+// An UnsyncTree never waits for a lock : the lock counters are always 0
+func (t *UnsyncTree[K, V]) Stats() Stats {
+	t.removeExpired()
+	return t.stats()
+}
+
+// stats() returns the shape of the tree and its rotation counters
+func (t *core[K, V]) stats() Stats {
+	stats := Stats{Size: t.count, MinHeight: bits.Len(uint(t.count))}
+	if t.RootNode != nil {
+		stats.Height = t.RootNode.Depth()
+		stats.DepthHistogram = make([]int, stats.Height)
+		t.RootNode.depthHistogram(stats.DepthHistogram, 0)
+	}
+	stats.LeftRotations = t.probe.stats.rotations[rotationLeft]
+	stats.RightRotations = t.probe.stats.rotations[rotationRight]
+	stats.DoubleRotations = t.probe.stats.rotations[rotationDouble]
+	stats.BalancedClimbs = t.probe.stats.balancedClimbs
+	return stats
+}
+
+// depthHistogram() counts the nodes of the subtree in histogram, by depth (n being at depth index+1)
+func (n *Node[K, V]) depthHistogram(histogram []int, index int) {
+	histogram[index]++
+	if n.Previous != nil {
+		n.Previous.depthHistogram(histogram, index+1)
+	}
+	if n.Next != nil {
+		n.Next.depthHistogram(histogram, index+1)
+	}
+}
+
+// PublishExpvar() publishes the Stats of the tree as an expvar variable, computed at each read
+// Like expvar.Publish(), it panics if the name is already used
+func (t *Tree[K, V]) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return t.Stats()
+	}))
+}
+
+// MetricsHandler() returns an http.Handler serving the Stats of the trees in the Prometheus text format
+// Each tree is labelled with its name in the map
+func MetricsHandler(trees map[string]StatsReporter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		//the metrics are written in a buffer first : an error is answered before any of them is sent
+		var buffer bytes.Buffer
+		if err := WriteMetrics(&buffer, trees); err != nil {
+			http.Error(w, fmt.Sprintf("unable to write the metrics : %s", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(buffer.Bytes()) //an error means the client is gone : there is nothing left to write
+	})
+}
+
+// WriteMetrics() writes the Stats of the trees in the Prometheus text format
+// Each tree is labelled with its name in the map
+func WriteMetrics(w io.Writer, trees map[string]StatsReporter) error {
+	names := make([]string, 0, len(trees))
+	for name := range trees {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	stats := make([]Stats, len(names))
+	for i, name := range names {
+		stats[i] = trees[name].Stats()
+	}
+
+	b := bufio.NewWriter(w)
+	family := func(name, kind, help string, sample func(label string, s Stats)) {
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+		for i, s := range stats {
+			sample(`tree="`+escapeLabel(names[i])+`"`, s)
+		}
+	}
+	metric := func(name, kind, help string, value func(s Stats) float64) {
+		family(name, kind, help, func(label string, s Stats) {
+			fmt.Fprintf(b, "%s{%s} %v\n", name, label, value(s))
+		})
+	}
+
+	metric("avlgo_size", "gauge", "Number of entries of the tree.", func(s Stats) float64 { return float64(s.Size) })
+	metric("avlgo_height", "gauge", "Height of the tree.", func(s Stats) float64 { return float64(s.Height) })
+	metric("avlgo_min_height", "gauge", "Smallest possible height for the number of entries.", func(s Stats) float64 { return float64(s.MinHeight) })
+	family("avlgo_node_depth", "histogram", "Depth of the nodes of the tree.", func(label string, s Stats) {
+		count, sum := 0, 0
+		for i, nodes := range s.DepthHistogram {
+			count += nodes
+			sum += nodes * (i + 1)
+			fmt.Fprintf(b, "avlgo_node_depth_bucket{%s,le=\"%d\"} %d\n", label, i+1, count)
+		}
+		fmt.Fprintf(b, "avlgo_node_depth_bucket{%s,le=\"+Inf\"} %d\n", label, count)
+		fmt.Fprintf(b, "avlgo_node_depth_sum{%s} %d\n", label, sum)
+		fmt.Fprintf(b, "avlgo_node_depth_count{%s} %d\n", label, count)
+	})
+	family("avlgo_rotations_total", "counter", "Rotations performed to rebalance the tree.", func(label string, s Stats) {
+		fmt.Fprintf(b, "avlgo_rotations_total{%s,kind=\"left\"} %d\n", label, s.LeftRotations)
+		fmt.Fprintf(b, "avlgo_rotations_total{%s,kind=\"right\"} %d\n", label, s.RightRotations)
+		fmt.Fprintf(b, "avlgo_rotations_total{%s,kind=\"double\"} %d\n", label, s.DoubleRotations)
+	})
+	metric("avlgo_balanced_climbs_total", "counter", "Times the rebalancing went on past an already balanced node.", func(s Stats) float64 { return float64(s.BalancedClimbs) })
+	metric("avlgo_lock_waits_total", "counter", "Locks which waited for another goroutine.", func(s Stats) float64 { return float64(s.LockWaits) })
+	metric("avlgo_lock_wait_seconds_total", "counter", "Total time spent waiting for the lock.", func(s Stats) float64 { return s.LockWaitTime.Seconds() })
+	metric("avlgo_lock_wait_seconds_max", "gauge", "Longest wait for the lock.", func(s Stats) float64 { return s.MaxLockWait.Seconds() })
+
+	return b.Flush()
+}
+
+// escapeLabel() escapes a Prometheus label value
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
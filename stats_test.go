@@ -0,0 +1,154 @@
+package avlgo
+
+import (
+	"errors"
+	"expvar"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantTree[int, int](v)
+		if stats := tree.Stats(); stats.Size != 0 || stats.Height != 0 || stats.MinHeight != 0 || len(stats.DepthHistogram) != 0 {
+			t.Errorf("stats of an empty tree are %+v", stats)
+		}
+
+		//0, 1, 2 : a left rotation
+		for i := 0; i < 3; i++ {
+			tree.PutOne(i, i)
+		}
+		if stats := tree.Stats(); stats.LeftRotations != 1 || stats.RightRotations != 0 || stats.DoubleRotations != 0 {
+			t.Errorf("rotations are %d, %d, %d, want 1, 0, 0", stats.LeftRotations, stats.RightRotations, stats.DoubleRotations)
+		}
+		//-2, -1 : a right rotation under 0
+		tree.PutOne(-1, 0)
+		tree.PutOne(-2, 0)
+		if stats := tree.Stats(); stats.RightRotations != 1 {
+			t.Errorf("right rotations are %d, want 1", stats.RightRotations)
+		}
+		//4, 3 : a double rotation under 2
+		tree.PutOne(4, 0)
+		tree.PutOne(3, 0)
+		if stats := tree.Stats(); stats.DoubleRotations != 1 {
+			t.Errorf("double rotations are %d, want 1", stats.DoubleRotations)
+		}
+
+		stats := tree.Stats()
+		if stats.Size != 7 || stats.Height != 3 || stats.MinHeight != 3 {
+			t.Errorf("size, height and min height are %d, %d and %d, want 7, 3 and 3", stats.Size, stats.Height, stats.MinHeight)
+		}
+		if want := []int{1, 2, 4}; len(stats.DepthHistogram) != 3 || stats.DepthHistogram[0] != 1 || stats.DepthHistogram[1] != 2 || stats.DepthHistogram[2] != 4 {
+			t.Errorf("depth histogram is %v, want %v", stats.DepthHistogram, want)
+		}
+		if stats.BalancedClimbs == 0 {
+			t.Errorf("balanced climbs shouldn't be 0")
+		}
+
+		//nodes which don't belong to a tree have no stats : their rebalancing must not fail
+		node := &Node[int, int]{Key: 0}
+		node.Put(1, 1)
+		node.RootNode().Put(2, 2)
+	})
+}
+
+func TestStatsLockWaits(t *testing.T) {
+	tree := NewTree[int, int]()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				tree.PutOne(g*1000+i, i)
+				tree.Get(i)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	stats := tree.Stats()
+	if stats.LockWaits > 0 && (stats.LockWaitTime <= 0 || stats.MaxLockWait <= 0 || stats.MaxLockWait > stats.LockWaitTime) {
+		t.Errorf("%d lock waits for %s (max %s)", stats.LockWaits, stats.LockWaitTime, stats.MaxLockWait)
+	}
+	if stats.Size != 8000 {
+		t.Errorf("size is %d, want 8000", stats.Size)
+	}
+
+	unsync := NewUnsyncTree[int, int]()
+	unsync.PutOne(1, 1)
+	if stats := unsync.Stats(); stats.LockWaits != 0 {
+		t.Errorf("lock waits of an unsync tree are %d, want 0", stats.LockWaits)
+	}
+
+	//a ShardedTree counts the waits for the locks of its shards
+	sharded := NewShardedTree[int, int]([]int{10}, 0)
+	s, _ := sharded.lockShard(20)
+	done := make(chan struct{})
+	go func() {
+		sharded.PutOne(30, 30)
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	s.tree.unlock()
+	<-done
+	if stats := sharded.Stats(); stats.LockWaits != 1 || stats.MaxLockWait <= 0 || stats.Size != 1 {
+		t.Errorf("sharded stats are %+v, want 1 lock wait and a size of 1", stats)
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	a, b := NewTree[int, int](), NewTree[string, int]()
+	for i := 0; i < 3; i++ {
+		a.PutOne(i, i)
+	}
+	b.PutOne("x", 1)
+
+	recorder := httptest.NewRecorder()
+	MetricsHandler(map[string]StatsReporter{"a": a, `b"2`: b}).ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+	for _, want := range []string{
+		"# TYPE avlgo_size gauge\navlgo_size{tree=\"a\"} 3\navlgo_size{tree=\"b\\\"2\"} 1\n",
+		"avlgo_rotations_total{tree=\"a\",kind=\"left\"} 1\n",
+		"avlgo_node_depth_bucket{tree=\"a\",le=\"1\"} 1\n",
+		"avlgo_node_depth_bucket{tree=\"a\",le=\"2\"} 3\n",
+		"avlgo_node_depth_sum{tree=\"a\"} 5\n",
+		"# TYPE avlgo_lock_wait_seconds_total counter\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics don't contain %q :\n%s", want, body)
+		}
+	}
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+		t.Errorf("content type is %q, want text/plain", contentType)
+	}
+}
+
+// brokenWriter is a writer whose client is gone
+type brokenWriter struct{}
+
+func (brokenWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestWriteMetricsError(t *testing.T) {
+	tree := NewTree[int, int]()
+	tree.PutOne(1, 1)
+	if err := WriteMetrics(brokenWriter{}, map[string]StatsReporter{"a": tree}); err == nil {
+		t.Errorf("WriteMetrics returns no error on a broken writer")
+	}
+}
+
+func TestPublishExpvar(t *testing.T) {
+	tree := NewTree[int, int]()
+	tree.PutOne(1, 1)
+	tree.PublishExpvar("avlgo_test_tree")
+
+	value := expvar.Get("avlgo_test_tree").String()
+	if !strings.Contains(value, `"Size":1`) {
+		t.Errorf("expvar value is %s, want the size", value)
+	}
+}
@@ -0,0 +1,259 @@
+// Package timeseries stores numeric samples in a Tree keyed by their timestamps, and answers the queries
+// every metric store needs : the samples of a window, downsampling into fixed buckets, retention by age
+// and the value at a given time
+//
+// A Series is keyed by int64 nanoseconds or by time.Time. Both are stored as nanoseconds since the Unix epoch,
+// so a time.Time must be between the years 1678 and 2262, and it is returned in UTC
+package timeseries
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/darthyoh/avlgo/v2"
+)
+
+// Timestamp is the constraint of the keys of a Series : nanoseconds since the Unix epoch, or a time.Time
+type Timestamp interface {
+	int64 | time.Time
+}
+
+// Point is a sample of a Series
+type Point[T Timestamp, V avlgo.Number] struct {
+	Time  T
+	Value V
+}
+
+// Aggregation is the function reducing the samples of a bucket in Downsample()
+type Aggregation int
+
+const (
+	Min   Aggregation = iota //smallest value of the bucket
+	Max                      //biggest value of the bucket
+	Mean                     //arithmetic mean of the values of the bucket
+	Last                     //value of the latest sample of the bucket
+	Count                    //number of samples in the bucket
+)
+
+// String() returns the name of the aggregation
+func (a Aggregation) String() string {
+	switch a {
+	case Min:
+		return "min"
+	case Max:
+		return "max"
+	case Mean:
+		return "mean"
+	case Last:
+		return "last"
+	case Count:
+		return "count"
+	}
+	return fmt.Sprintf("Aggregation(%d)", int(a))
+}
+
+// ErrInvalidStep is returned by Downsample() for a step which isn't positive
+var ErrInvalidStep = errors.New("timeseries: the step must be positive")
+
+// ErrUnknownAggregation is returned by Downsample() for an aggregation which isn't one of the constants
+var ErrUnknownAggregation = errors.New("timeseries: unknown aggregation")
+
+// Series is a time series : at most one value per timestamp, in time order
+// It is safe for concurrent use, like the Tree holding it
+type Series[T Timestamp, V avlgo.Number] struct {
+	tree      *avlgo.Tree[int64, V]
+	mutex     sync.Mutex //guards retention and now
+	retention time.Duration
+	now       func() time.Time
+}
+
+// New() returns an empty new Series, without retention
+func New[T Timestamp, V avlgo.Number]() *Series[T, V] {
+	return &Series[T, V]{tree: avlgo.NewTree[int64, V](), now: time.Now}
+}
+
+// Tree() returns the tree holding the samples, keyed by nanoseconds since the Unix epoch
+// It is mainly useful to encode the series, or to read its stats
+func (s *Series[T, V]) Tree() *avlgo.Tree[int64, V] {
+	return s.tree
+}
+
+// Len() returns the number of samples of the series
+func (s *Series[T, V]) Len() int {
+	return s.tree.Size()
+}
+
+// Append() adds a sample to the series, replacing the value if a sample has the same timestamp
+// If a retention is set, the samples older than the retention are removed
+// Samples don't have to be appended in time order
+func (s *Series[T, V]) Append(t T, value V) {
+	s.tree.PutOne(nanos(t), value)
+	s.Prune()
+}
+
+// At() returns the last sample at or before t (ok is false if there is none)
+func (s *Series[T, V]) At(t T) (point Point[T, V], ok bool) {
+	key, value, ok := s.tree.Floor(nanos(t))
+	if !ok {
+		return point, false
+	}
+	return Point[T, V]{Time: fromNanos[T](key), Value: value}, true
+}
+
+// Window() returns, in time order, the samples from start (included) to end (excluded)
+// It returns nothing if end isn't after start
+func (s *Series[T, V]) Window(start, end T) (points []Point[T, V]) {
+	from, to := nanos(start), nanos(end)
+	if to <= from {
+		return nil
+	}
+	s.tree.AscendRange(avlgo.Inclusive(from), avlgo.Exclusive(to), func(key int64, value V) bool {
+		points = append(points, Point[T, V]{Time: fromNanos[T](key), Value: value})
+		return true
+	})
+	return points
+}
+
+// Downsample() reduces the samples of the series with agg, in buckets of step : one point per non-empty bucket,
+// in time order, timestamped with the start of its bucket
+// Buckets are aligned on the Unix epoch (a bucket of one minute starts at a round minute), so downsampling
+// two series with the same step gives comparable points. The values are converted to float64, for the mean
+func (s *Series[T, V]) Downsample(step time.Duration, agg Aggregation) ([]Point[T, float64], error) {
+	if step <= 0 {
+		return nil, ErrInvalidStep
+	}
+	if agg < Min || agg > Count {
+		return nil, fmt.Errorf("unable to downsample with %s : %w", agg, ErrUnknownAggregation)
+	}
+
+	points := make([]Point[T, float64], 0)
+	var current bucket
+	s.tree.Ascend(func(key int64, value V) bool {
+		if start := bucketStart(key, int64(step)); current.count == 0 || start != current.start {
+			if current.count > 0 {
+				points = append(points, Point[T, float64]{Time: fromNanos[T](current.start), Value: current.aggregate(agg)})
+			}
+			current = bucket{start: start}
+		}
+		current.add(float64(value))
+		return true
+	})
+	if current.count > 0 {
+		points = append(points, Point[T, float64]{Time: fromNanos[T](current.start), Value: current.aggregate(agg)})
+	}
+	return points, nil
+}
+
+// SetRetention() sets the maximum age of the samples, from the clock of the series : older samples are removed
+// at each Append(), or by Prune(). A retention of 0 (the default) keeps every sample
+// The samples already too old are removed at once
+func (s *Series[T, V]) SetRetention(age time.Duration) {
+	s.mutex.Lock()
+	s.retention = age
+	s.mutex.Unlock()
+	s.Prune()
+}
+
+// SetClock() replaces the clock used to measure the age of the samples (time.Now by default)
+// It is mainly useful for testing
+func (s *Series[T, V]) SetClock(now func() time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.now = now
+}
+
+// Prune() removes the samples older than the retention and returns the number of removed samples
+// It is called by Append() : call it directly to apply the retention to a series which isn't appended to anymore
+func (s *Series[T, V]) Prune() int {
+	s.mutex.Lock()
+	retention, now := s.retention, s.now
+	s.mutex.Unlock()
+	if retention <= 0 {
+		return 0
+	}
+
+	current := now().UnixNano()
+	cutoff := current - int64(retention)
+	if cutoff > current { //the subtraction wrapped around : nothing is that old
+		return 0
+	}
+	//most appends have nothing to remove : the oldest sample tells it without locking the tree for writing
+	if oldest, _, ok := s.tree.Ceiling(math.MinInt64); !ok || oldest >= cutoff {
+		return 0
+	}
+	removed, _ := s.tree.DeleteRange(avlgo.Unbounded[int64](), avlgo.Exclusive(cutoff)) //an unbounded range is valid
+	return removed
+}
+
+// bucket accumulates the values of a bucket of Downsample()
+type bucket struct {
+	start               int64
+	count               int
+	min, max, sum, last float64
+}
+
+// add() adds a value to the bucket
+func (b *bucket) add(value float64) {
+	if b.count == 0 || value < b.min {
+		b.min = value
+	}
+	if b.count == 0 || value > b.max {
+		b.max = value
+	}
+	b.sum += value
+	b.last = value
+	b.count++
+}
+
+// aggregate() returns the value of the bucket for agg
+func (b bucket) aggregate(agg Aggregation) float64 {
+	switch agg {
+	case Min:
+		return b.min
+	case Max:
+		return b.max
+	case Mean:
+		return b.sum / float64(b.count)
+	case Last:
+		return b.last
+	}
+	return float64(b.count)
+}
+
+// bucketStart() returns the start of the bucket of step holding ns, rounding down for negative timestamps too
+// The first bucket starts at math.MinInt64 when its start would be before it
+func bucketStart(ns, step int64) int64 {
+	offset := ns % step
+	if offset < 0 {
+		offset += step
+	}
+	if ns < math.MinInt64+offset { //ns - offset would wrap around
+		return math.MinInt64
+	}
+	return ns - offset
+}
+
+// nanos() returns the timestamp in nanoseconds since the Unix epoch
+func nanos[T Timestamp](t T) (ns int64) {
+	switch t := any(t).(type) {
+	case int64:
+		ns = t
+	case time.Time:
+		ns = t.UnixNano()
+	}
+	return ns
+}
+
+// fromNanos() returns the timestamp of ns nanoseconds since the Unix epoch
+func fromNanos[T Timestamp](ns int64) (t T) {
+	switch p := any(&t).(type) {
+	case *int64:
+		*p = ns
+	case *time.Time:
+		*p = time.Unix(0, ns).UTC()
+	}
+	return t
+}
@@ -0,0 +1,206 @@
+package timeseries
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// base is a round hour : the buckets of the tests start at it
+var base = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestAppendAndAt(t *testing.T) {
+	series := New[time.Time, float64]()
+	series.Append(base.Add(2*time.Second), 2)
+	series.Append(base, 0)
+	series.Append(base.Add(time.Second), 1)
+	series.Append(base.Add(time.Second), 1.5) //same timestamp : the value is replaced
+
+	if series.Len() != 3 {
+		t.Errorf("Len is %d, want 3", series.Len())
+	}
+	for _, test := range []struct {
+		at    time.Time
+		want  time.Time
+		value float64
+	}{
+		{base, base, 0},
+		{base.Add(1500 * time.Millisecond), base.Add(time.Second), 1.5},
+		{base.Add(time.Hour), base.Add(2 * time.Second), 2},
+	} {
+		point, ok := series.At(test.at)
+		if !ok || !point.Time.Equal(test.want) || point.Value != test.value {
+			t.Errorf("At(%s) returns %v, %v, want %s = %g", test.at, point, ok, test.want, test.value)
+		}
+	}
+	if point, ok := series.At(base.Add(-time.Nanosecond)); ok {
+		t.Errorf("At() before the first sample returns %v, want nothing", point)
+	}
+	if point, _ := series.At(base); point.Time.Location() != time.UTC {
+		t.Errorf("the time of a point is in %s, want UTC", point.Time.Location())
+	}
+}
+
+func TestWindow(t *testing.T) {
+	series := New[int64, int]()
+	for ns := int64(0); ns < 10; ns++ {
+		series.Append(ns*10, int(ns))
+	}
+
+	want := []Point[int64, int]{{20, 2}, {30, 3}, {40, 4}}
+	if points := series.Window(20, 50); !reflect.DeepEqual(points, want) {
+		t.Errorf("Window(20, 50) returns %v, want %v", points, want)
+	}
+	if points := series.Window(15, 25); !reflect.DeepEqual(points, want[:1]) {
+		t.Errorf("Window(15, 25) returns %v, want %v", points, want[:1])
+	}
+	for _, bounds := range [][2]int64{{50, 50}, {50, 20}, {100, 200}} {
+		if points := series.Window(bounds[0], bounds[1]); points != nil {
+			t.Errorf("Window(%d, %d) returns %v, want nothing", bounds[0], bounds[1], points)
+		}
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	series := New[time.Time, int]()
+	//two samples in the first minute, none in the second, three in the third
+	samples := map[time.Duration]int{
+		10 * time.Second:  4,
+		50 * time.Second:  2,
+		125 * time.Second: 9,
+		130 * time.Second: 1,
+		175 * time.Second: 5,
+	}
+	for offset, value := range samples {
+		series.Append(base.Add(offset), value)
+	}
+
+	for _, test := range []struct {
+		agg  Aggregation
+		want []float64
+	}{
+		{Min, []float64{2, 1}},
+		{Max, []float64{4, 9}},
+		{Mean, []float64{3, 5}},
+		{Last, []float64{2, 5}},
+		{Count, []float64{2, 3}},
+	} {
+		points, err := series.Downsample(time.Minute, test.agg)
+		if err != nil {
+			t.Fatalf("Downsample(%s) shouldn't return an error. %s is returned", test.agg, err)
+		}
+		if len(points) != 2 {
+			t.Fatalf("Downsample(%s) returns %d points, want 2", test.agg, len(points))
+		}
+		for i, start := range []time.Time{base, base.Add(2 * time.Minute)} {
+			if !points[i].Time.Equal(start) || points[i].Value != test.want[i] {
+				t.Errorf("point %d of Downsample(%s) is %v, want %s = %g", i, test.agg, points[i], start, test.want[i])
+			}
+		}
+	}
+
+	if _, err := series.Downsample(0, Mean); !errors.Is(err, ErrInvalidStep) {
+		t.Errorf("Downsample with a step of 0 returns %v, want ErrInvalidStep", err)
+	}
+	if _, err := series.Downsample(time.Minute, Aggregation(42)); !errors.Is(err, ErrUnknownAggregation) {
+		t.Errorf("Downsample with an unknown aggregation returns %v, want ErrUnknownAggregation", err)
+	}
+	if points, err := New[int64, int]().Downsample(time.Minute, Mean); err != nil || len(points) != 0 {
+		t.Errorf("Downsample of an empty series returns %v, %v, want nothing", points, err)
+	}
+}
+
+func TestDownsampleNegativeTimestamps(t *testing.T) {
+	series := New[int64, int]()
+	for _, ns := range []int64{-15, -10, -5, 0, 5} {
+		series.Append(ns, 1)
+	}
+	points, _ := series.Downsample(10, Count)
+	want := []Point[int64, float64]{{-20, 1}, {-10, 2}, {0, 2}}
+	if !reflect.DeepEqual(points, want) {
+		t.Errorf("Downsample returns %v, want %v", points, want)
+	}
+}
+
+func TestDownsampleMinInt64(t *testing.T) {
+	series := New[int64, int]()
+	for _, ns := range []int64{math.MinInt64, math.MinInt64 + 1, math.MinInt64 + 10} {
+		series.Append(ns, 1)
+	}
+	points, err := series.Downsample(10, Count)
+	if err != nil {
+		t.Fatalf("Downsample returns %v", err)
+	}
+	//MinInt64 is not a multiple of 10 : its bucket would start 2ns before it
+	want := []Point[int64, float64]{{math.MinInt64, 2}, {math.MinInt64 + 8, 1}}
+	if !reflect.DeepEqual(points, want) {
+		t.Errorf("Downsample returns %v, want %v", points, want)
+	}
+	if start := bucketStart(math.MinInt64, math.MaxInt64); start != math.MinInt64 {
+		t.Errorf("bucketStart is %d, want %d", start, int64(math.MinInt64))
+	}
+}
+
+func TestRetention(t *testing.T) {
+	now := base
+	series := New[time.Time, float64]()
+	series.SetClock(func() time.Time { return now })
+	for i := 0; i < 10; i++ {
+		series.Append(base.Add(time.Duration(i-9)*time.Minute), float64(i))
+	}
+
+	//setting the retention removes the samples already too old
+	series.SetRetention(5 * time.Minute)
+	if series.Len() != 6 {
+		t.Errorf("Len is %d after SetRetention, want 6", series.Len())
+	}
+	if point, ok := series.At(base.Add(-5*time.Minute - time.Second)); ok {
+		t.Errorf("At() before the retention returns %v, want nothing", point)
+	}
+
+	//appending removes the samples which became too old
+	now = now.Add(2 * time.Minute)
+	series.Append(now, 10)
+	if series.Len() != 5 {
+		t.Errorf("Len is %d after Append, want 5", series.Len())
+	}
+	if points := series.Window(base.Add(-time.Hour), now.Add(time.Second)); points[0].Value != 6 {
+		t.Errorf("the oldest sample is %v, want the value 6", points[0])
+	}
+
+	now = now.Add(time.Hour)
+	if removed := series.Prune(); removed != 5 || series.Len() != 0 {
+		t.Errorf("Prune removes %d samples, leaving %d, want 5 and 0", removed, series.Len())
+	}
+
+	//without retention, nothing is removed
+	series.SetRetention(0)
+	series.Append(base, 1)
+	if removed := series.Prune(); removed != 0 || series.Len() != 1 {
+		t.Errorf("Prune without retention removes %d samples, want 0", removed)
+	}
+}
+
+func TestConcurrentAppends(t *testing.T) {
+	series := New[int64, int]()
+	series.SetRetention(time.Hour)
+	start := time.Now().UnixNano()
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				series.Append(start+int64(w*1000+i), i)
+				series.Window(start, start+int64(w*1000+i))
+			}
+		}(w)
+	}
+	wg.Wait()
+	if series.Len() != 400 {
+		t.Errorf("Len is %d, want 400", series.Len())
+	}
+}
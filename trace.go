@@ -0,0 +1,235 @@
+package avlgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// TraceEventType is the kind of step of an operation reported to a Tracer
+type TraceEventType int
+
+const (
+	TraceDescend        TraceEventType = iota //the operation goes down to the node of Key
+	TraceInsertLeaf                           //a leaf is added for Key
+	TraceReplace                              //the value of Key is replaced (the shape doesn't change)
+	TraceRotateLeft                           //the node of Key is rotated to the left
+	TraceRotateRight                          //the node of Key is rotated to the right
+	TraceDoubleRotation                       //the node of Key is unbalanced by its inner grandchild : two rotations follow
+	TraceSuccessorSwap                        //the node of Key, having two children, is swapped with its successor
+	TraceRemove                               //the node of Key is unlinked from the tree
+)
+
+// String() returns the name of the event type
+func (e TraceEventType) String() string {
+	switch e {
+	case TraceDescend:
+		return "descend"
+	case TraceInsertLeaf:
+		return "insert leaf"
+	case TraceReplace:
+		return "replace"
+	case TraceRotateLeft:
+		return "rotate left"
+	case TraceRotateRight:
+		return "rotate right"
+	case TraceDoubleRotation:
+		return "double rotation"
+	case TraceSuccessorSwap:
+		return "successor swap"
+	case TraceRemove:
+		return "remove"
+	default:
+		return fmt.Sprintf("TraceEventType(%d)", int(e))
+	}
+}
+
+// TraceEvent is a step of an operation on a Tree
+// Root is the root node of the tree once the step is done (nil if the tree is empty) :
+// it may only be read during the call to the Tracer
+type TraceEvent[K Ordered, V any] struct {
+	Type TraceEventType
+	Key  K
+	Root *Node[K, V]
+}
+
+// Tracer receives the steps of the operations changing a Tree (see Tree.SetTracer())
+type Tracer[K Ordered, V any] interface {
+	Trace(event TraceEvent[K, V])
+}
+
+// TracerFunc is a function used as a Tracer
+type TracerFunc[K Ordered, V any] func(event TraceEvent[K, V])
+
+// Trace() calls f(event)
+func (f TracerFunc[K, V]) Trace(event TraceEvent[K, V]) {
+	f(event)
+}
+
+// SetTracer() sets the tracer receiving the steps of the following puts and deletes (nil to stop tracing) :
+// the nodes visited while searching a key, the inserted leaves, the rotations and the removed nodes, in order
+// The tracer is called with the tree locked, so it must not use the tree
+func (t *Tree[K, V]) SetTracer(tracer Tracer[K, V]) {
+	t.lock()
+	defer t.unlock()
+	t.probe.tracer = tracer
+}
+
+// SetTracer() sets the tracer receiving the steps of the following puts and deletes (nil to stop tracing),
+// like Tree.SetTracer()
+func (t *UnsyncTree[K, V]) SetTracer(tracer Tracer[K, V]) {
+	t.probe.tracer = tracer
+}
+
+// probe is what a Tree passes to the operations on its nodes : the counters of its stats and its tracer
+// Its methods do nothing on a nil probe (for the nodes which don't belong to a Tree)
+type probe[K Ordered, V any] struct {
+	stats  treeStats
+	tracer Tracer[K, V]
+}
+
+// probeOf() returns a probe tracing with tracer, for the operations on a Node without Tree (nil if tracer is nil)
+// Its counters are dropped : only a Tree reports them
+func probeOf[K Ordered, V any](tracer Tracer[K, V]) *probe[K, V] {
+	if tracer == nil {
+		return nil
+	}
+	return &probe[K, V]{tracer: tracer}
+}
+
+// rotated() counts a rotation
+func (p *probe[K, V]) rotated(kind rotationKind) {
+	if p != nil {
+		p.stats.rotated(kind)
+	}
+}
+
+// climbed() counts a climb past a balanced node
+func (p *probe[K, V]) climbed() {
+	if p != nil {
+		p.stats.climbed()
+	}
+}
+
+// trace() reports an event about key to the tracer. in is any node of the tree (nil if the tree is empty)
+func (p *probe[K, V]) trace(eventType TraceEventType, key K, in *Node[K, V]) {
+	if p == nil || p.tracer == nil {
+		return
+	}
+	var root *Node[K, V]
+	if in != nil {
+		root = in.RootNode()
+	}
+	p.tracer.Trace(TraceEvent[K, V]{Type: eventType, Key: key, Root: root})
+}
+
+// FrameNode is a node of the tree recorded in a Frame
+type FrameNode[K Ordered] struct {
+	Key      K             `json:"key"`
+	Previous *FrameNode[K] `json:"previous,omitempty"`
+	Next     *FrameNode[K] `json:"next,omitempty"`
+}
+
+// Frame is a step recorded by a Recorder, with the shape of the tree once the step is done
+type Frame[K Ordered] struct {
+	Step  int           `json:"step"`
+	Event string        `json:"event"`
+	Key   K             `json:"key"`
+	Tree  *FrameNode[K] `json:"tree"`
+}
+
+// Recorder is a Tracer recording each step with a copy of the keys of the tree, to replay an operation
+// Use Frames(), ASCII() or JSON() to read the recorded steps
+type Recorder[K Ordered, V any] struct {
+	frames   []Frame[K]
+	descends bool
+}
+
+// NewRecorder() returns an empty Recorder. The descend steps are only recorded if withDescends is true
+func NewRecorder[K Ordered, V any](withDescends bool) *Recorder[K, V] {
+	return &Recorder[K, V]{descends: withDescends}
+}
+
+// Trace() records the event (it implements Tracer)
+func (r *Recorder[K, V]) Trace(event TraceEvent[K, V]) {
+	if event.Type == TraceDescend && !r.descends {
+		return
+	}
+	r.frames = append(r.frames, Frame[K]{
+		Step:  len(r.frames) + 1,
+		Event: event.Type.String(),
+		Key:   event.Key,
+		Tree:  newFrameNode(event.Root),
+	})
+}
+
+// Reset() forgets the recorded frames
+func (r *Recorder[K, V]) Reset() {
+	r.frames = nil
+}
+
+// Frames() returns the recorded frames
+func (r *Recorder[K, V]) Frames() []Frame[K] {
+	return r.frames
+}
+
+// JSON() returns the recorded frames in JSON : an array of {"step", "event", "key", "tree"}
+// where tree is a nested {"key", "previous", "next"} object
+func (r *Recorder[K, V]) JSON() ([]byte, error) {
+	frames := r.frames
+	if frames == nil {
+		frames = []Frame[K]{}
+	}
+	b, err := json.Marshal(frames)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal frames : %s", err)
+	}
+	return b, nil
+}
+
+// ASCII() returns the recorded frames drawn in text : each step is followed by the tree,
+// one node per line, the Previous child before the Next child (a missing child is drawn as a dot)
+//
+//	step 3 : rotate left at 1
+//	2
+//	+-- 1
+//	`-- 3
+func (r *Recorder[K, V]) ASCII() string {
+	var b strings.Builder
+	for _, frame := range r.frames {
+		fmt.Fprintf(&b, "step %d : %s at %v\n", frame.Step, frame.Event, frame.Key)
+		if frame.Tree == nil {
+			b.WriteString("(empty)\n")
+		} else {
+			frame.Tree.draw(&b, "", "")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// newFrameNode() returns a copy of the keys of the subtree of the node
+func newFrameNode[K Ordered, V any](n *Node[K, V]) *FrameNode[K] {
+	if n == nil {
+		return nil
+	}
+	return &FrameNode[K]{Key: n.Key, Previous: newFrameNode(n.Previous), Next: newFrameNode(n.Next)}
+}
+
+// draw() writes the subtree of the node, the node line starting with prefix and its children lines with childPrefix
+func (n *FrameNode[K]) draw(b *strings.Builder, prefix, childPrefix string) {
+	fmt.Fprintf(b, "%s%v\n", prefix, n.Key)
+	if n.Previous == nil && n.Next == nil {
+		return
+	}
+	if n.Previous == nil {
+		b.WriteString(childPrefix + "+-- .\n")
+	} else {
+		n.Previous.draw(b, childPrefix+"+-- ", childPrefix+"|   ")
+	}
+	if n.Next == nil {
+		b.WriteString(childPrefix + "`-- .\n")
+	} else {
+		n.Next.draw(b, childPrefix+"`-- ", childPrefix+"    ")
+	}
+}
@@ -0,0 +1,150 @@
+package avlgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func eventsOf(frames []Frame[int]) (events []string) {
+	for _, frame := range frames {
+		events = append(events, fmt.Sprintf("%s %d", frame.Event, frame.Key))
+	}
+	return events
+}
+
+func TestTracerPut(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantTree[int, int](v)
+		recorder := NewRecorder[int, int](true)
+		tree.SetTracer(recorder)
+
+		for i := 1; i <= 3; i++ {
+			tree.PutOne(i, i)
+		}
+		want := []string{
+			"insert leaf 1",
+			"descend 1", "insert leaf 2",
+			"descend 1", "descend 2", "insert leaf 3", "rotate left 1",
+		}
+		if events := eventsOf(recorder.Frames()); !reflect.DeepEqual(events, want) {
+			t.Errorf("events are %v, want %v", events, want)
+		}
+
+		//a double rotation : 3 under 1, 2 under 3
+		recorder.Reset()
+		tree = newVariantTree[int, int](v)
+		tree.SetTracer(recorder)
+		for _, k := range []int{1, 3, 2} {
+			tree.PutOne(k, k)
+		}
+		tree.PutOne(2, 20)
+		want = []string{
+			"insert leaf 1", "descend 1", "insert leaf 3", "descend 1", "descend 3", "insert leaf 2",
+			"double rotation 1", "rotate right 3", "rotate left 1",
+			"descend 2", "replace 2",
+		}
+		if events := eventsOf(recorder.Frames()); !reflect.DeepEqual(events, want) {
+			t.Errorf("events are %v, want %v", events, want)
+		}
+		if stats := tree.Stats(); stats.DoubleRotations != 1 || stats.LeftRotations != 0 || stats.RightRotations != 0 {
+			t.Errorf("rotations are %d, %d, %d, want 0, 0, 1", stats.LeftRotations, stats.RightRotations, stats.DoubleRotations)
+		}
+
+		//the frames hold the shape of the tree after each step
+		frames := recorder.Frames()
+		if frame := frames[7]; frame.Tree.Key != 1 || frame.Tree.Next.Key != 2 || frame.Tree.Next.Next.Key != 3 {
+			t.Errorf("tree after the right rotation is %+v", frame.Tree)
+		}
+		if ascii, want := recorder.ASCII(), "step 9 : rotate left at 1\n2\n+-- 1\n`-- 3\n\n"; !strings.Contains(ascii, want) {
+			t.Errorf("ASCII frames are\n%s\nwant them to contain\n%s", ascii, want)
+		}
+	})
+}
+
+func TestTracerDelete(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantTree[int, int](v)
+		for _, k := range []int{2, 1, 4, 3} {
+			tree.PutOne(k, k)
+		}
+		recorder := NewRecorder[int, int](false)
+		tree.SetTracer(recorder)
+
+		//2 has two children : it is swapped with its successor 3, then removed
+		tree.Delete(2)
+		want := []string{"successor swap 2", "remove 2"}
+		if events := eventsOf(recorder.Frames()); !reflect.DeepEqual(events, want) {
+			t.Errorf("events are %v, want %v", events, want)
+		}
+		if ascii, want := recorder.ASCII(), "step 1 : successor swap at 2\n3\n+-- 1\n`-- 4\n    +-- 2\n    `-- .\n\nstep 2 : remove at 2\n3\n+-- 1\n`-- 4\n\n"; ascii != want {
+			t.Errorf("ASCII frames are\n%s\nwant\n%s", ascii, want)
+		}
+
+		recorder.Reset()
+		tree.Delete(1, 3, 4)
+		want = []string{"remove 1", "remove 3", "remove 4"}
+		if events := eventsOf(recorder.Frames()); !reflect.DeepEqual(events, want) {
+			t.Errorf("events are %v, want %v", events, want)
+		}
+		if frames := recorder.Frames(); frames[len(frames)-1].Tree != nil {
+			t.Errorf("the last frame should be an empty tree")
+		}
+
+		b, err := recorder.JSON()
+		if err != nil {
+			t.Fatalf("JSON shouldn't return an error. %s is returned", err)
+		}
+		var decoded []map[string]any
+		if err := json.Unmarshal(b, &decoded); err != nil || len(decoded) != 3 {
+			t.Fatalf("JSON frames are %s (%v)", b, err)
+		}
+		if decoded[0]["event"] != "remove" || decoded[0]["key"] != 1.0 || decoded[0]["tree"].(map[string]any)["key"] != 3.0 {
+			t.Errorf("first JSON frame is %v", decoded[0])
+		}
+
+		//no more trace
+		tree.SetTracer(nil)
+		recorder.Reset()
+		tree.PutOne(1, 1)
+		if len(recorder.Frames()) != 0 {
+			t.Errorf("%d frames recorded without tracer, want 0", len(recorder.Frames()))
+		}
+	})
+}
+
+func TestTracerNode(t *testing.T) {
+	recorder := NewRecorder[int, int](true)
+	root := &Node[int, int]{Key: 1, Value: 1, depth: 1}
+	for _, k := range []int{3, 2} {
+		root = root.PutTraced(k, k, recorder)
+	}
+	root = root.PutTraced(2, 20, recorder)
+	want := []string{
+		"descend 1", "insert leaf 3", "descend 1", "descend 3", "insert leaf 2",
+		"double rotation 1", "rotate right 3", "rotate left 1",
+		"descend 2", "replace 2",
+	}
+	if events := eventsOf(recorder.Frames()); !reflect.DeepEqual(events, want) {
+		t.Errorf("events are %v, want %v", events, want)
+	}
+
+	recorder.Reset()
+	root = root.Get(2).DeleteTraced(recorder)
+	want = []string{"successor swap 2", "remove 2"}
+	if events := eventsOf(recorder.Frames()); !reflect.DeepEqual(events, want) {
+		t.Errorf("events are %v, want %v", events, want)
+	}
+	if root.Key != 3 || root.Size() != 2 {
+		t.Errorf("root is %d with %d nodes, want 3 with 2 nodes", root.Key, root.Size())
+	}
+
+	//Put() and Delete() don't trace
+	recorder.Reset()
+	root.Put(5, 5).Get(1).Delete()
+	if len(recorder.Frames()) != 0 {
+		t.Errorf("%d frames recorded without tracer, want 0", len(recorder.Frames()))
+	}
+}
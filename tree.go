@@ -5,12 +5,49 @@ import (
 	"fmt"
 	"os"
 	"sync"
+	"time"
 )
 
 // Tree struct represents a AVL BinarySearch Tree (BST)
+// It is safe for concurrent use : every method locks the tree core (see UnsyncTree for a tree which never locks)
 type Tree[K Ordered, V any] struct {
-	rwMutex  sync.RWMutex //RWMutex for preventing concurrent writing operations
-	RootNode *Node[K, V]  //The root node of the Tree
+	rwMutex sync.RWMutex //RWMutex for preventing concurrent writing operations
+	waits   lockStats    //waits for the rwMutex, reported by Stats()
+	core[K, V]
+}
+
+// UnsyncTree is a Tree which never locks : it has the API of Tree, with the same core,
+// but it must be used by only one goroutine at a time
+// It is faster than a Tree, and doesn't pay for any atomic operation or mutex on its reads
+// (Put() is sequential, and there is no StartJanitor() nor PublishExpvar(), which would use it from other goroutines)
+type UnsyncTree[K Ordered, V any] struct {
+	core[K, V]
+}
+
+// AnyTree is a Tree or an UnsyncTree : the functions of the package (Filter(), Diff(), Nearest()...) take either
+// Its read() method is unexported, so no other type implements it
+// Before Go 1.21, the type arguments of these functions can't be inferred from an AnyTree : write them (Filter[int, string](...))
+type AnyTree[K Ordered, V any] interface {
+	DeleteRange(lo, hi Bound[K]) (int, error)
+	read(fn func(t *core[K, V]))
+}
+
+// core is the AVL tree shared by Tree and UnsyncTree. Its methods never lock :
+// Tree calls them with its rwMutex held, UnsyncTree calls them directly
+type core[K Ordered, V any] struct {
+	RootNode   *Node[K, V]      //The root node of the Tree
+	count      int              //number of nodes, maintained by storeAt() and removeNode()
+	expiration expiration[K, V] //expiration state of the keys put with PutWithTTL()
+	bounds     *bounds[K, V]    //capacity and eviction state of a bounded tree (nil if unbounded)
+	observers  *observers[K, V] //callbacks and watchers notified of the changes (nil until one is registered)
+	probe      probe[K, V]      //counters reported by Stats() and tracer of the structural changes
+}
+
+// encodedTree is what Encode() writes in the gob file
+// Deadlines holds the deadlines of the keys put with a TTL, in unix nanoseconds (files written before it decode without it)
+type encodedTree[K Ordered, V any] struct {
+	RootNode  *Node[K, V]
+	Deadlines map[K]int64
 }
 
 // NewTree() return an empty new Tree
@@ -18,8 +55,55 @@ func NewTree[K Ordered, V any]() *Tree[K, V] {
 	return &Tree[K, V]{}
 }
 
+// NewUnsyncTree() return an empty new UnsyncTree
+func NewUnsyncTree[K Ordered, V any]() *UnsyncTree[K, V] {
+	return &UnsyncTree[K, V]{}
+}
+
+// lock(), unlock(), rlock() and runlock() lock the tree
+// Every method of the Tree locks through them
+// The time spent waiting for a lock held by another goroutine is counted in the stats
+func (t *Tree[K, V]) lock() {
+	if !t.rwMutex.TryLock() {
+		start := time.Now()
+		t.rwMutex.Lock()
+		t.waits.waited(time.Since(start))
+	}
+}
+
+func (t *Tree[K, V]) unlock() {
+	t.rwMutex.Unlock()
+}
+
+func (t *Tree[K, V]) rlock() {
+	if !t.rwMutex.TryRLock() {
+		start := time.Now()
+		t.rwMutex.RLock()
+		t.waits.waited(time.Since(start))
+	}
+}
+
+func (t *Tree[K, V]) runlock() {
+	t.rwMutex.RUnlock()
+}
+
 // Encode() serialize the tree in gob format
+// The expired entries are removed first. The deadlines of the other entries put with a TTL are encoded :
+// they are absolute times, so an entry decoded after its deadline is expired
 func (t *Tree[K, V]) Encode(output string) error {
+	t.rlockLive()
+	defer t.runlock()
+	return t.encode(output)
+}
+
+// Encode() serialize the tree in gob format, like Tree.Encode()
+func (t *UnsyncTree[K, V]) Encode(output string) error {
+	t.removeExpired()
+	return t.encode(output)
+}
+
+// encode() writes the tree structure in the output file
+func (t *core[K, V]) encode(output string) error {
 	//simply encode the tree structure
 	file, err := os.Create(output)
 	if err != nil {
@@ -28,7 +112,7 @@ func (t *Tree[K, V]) Encode(output string) error {
 	defer file.Close()
 
 	encoder := gob.NewEncoder(file)
-	if err = encoder.Encode(t); err != nil {
+	if err = encoder.Encode(encodedTree[K, V]{RootNode: t.RootNode, Deadlines: t.expiration.deadlines}); err != nil {
 		return fmt.Errorf("unable to encode tree : %s", err)
 	}
 	return nil
@@ -37,7 +121,7 @@ func (t *Tree[K, V]) Encode(output string) error {
 // Decode() deserialize a tree from an input file
 func Decode[K Ordered, V any](input string) (*Tree[K, V], error) {
 	//first, decode the tree
-	tree := NewTree[K, V]()
+	var decoded encodedTree[K, V]
 	file, err := os.Open(input)
 	if err != nil {
 		return nil, fmt.Errorf("unable to open the input file : %s", err)
@@ -46,38 +130,68 @@ func Decode[K Ordered, V any](input string) (*Tree[K, V], error) {
 
 	decoder := gob.NewDecoder(file)
 
-	if err = decoder.Decode(&tree); err != nil {
+	if err = decoder.Decode(&decoded); err != nil {
 		return nil, fmt.Errorf("unable to decode tree : %s", err)
 	}
+	tree := NewTree[K, V]()
+	tree.RootNode = decoded.RootNode
 
 	//then, re-build the "parent" field of each Node (parent field is private, so not encoded by the Encode() method to prevent infinite loop while encoding)
 	if tree.RootNode != nil {
 		if !tree.RootNode.affectParentToChildren() {
-			return nil, fmt.Errorf("unable to decode tree : %s", err)
+			return nil, fmt.Errorf("unable to decode tree : unable to rebuild parent links")
+		}
+		tree.RootNode.resetDepths()
+		//a corrupted input may describe an unordered or unbalanced tree : reject it
+		if _, ok := tree.RootNode.isValid(); !ok {
+			return nil, fmt.Errorf("unable to decode tree : the decoded tree is not a valid AVL tree")
+		}
+		tree.count = tree.RootNode.Size()
+	}
+
+	//finally, restore the TTLs : the expired entries are removed by the first use of the tree
+	for key, deadline := range decoded.Deadlines {
+		if tree.RootNode == nil || tree.RootNode.Get(key) == nil {
+			return nil, fmt.Errorf("unable to decode tree : deadline of the missing key %v", key)
 		}
+		tree.setDeadline(key, deadline)
 	}
 
 	return tree, nil
 }
 
 // Size() returns the size (number of Nodes) of the Tree
-// Basically, it delegates the Size to its RootNode (or returns 0)
 func (t *Tree[K, V]) Size() int {
-	t.rwMutex.RLock()
-	defer t.rwMutex.RUnlock()
-	if t.RootNode == nil {
-		return 0
-	}
-	return t.RootNode.Size()
+	t.rlockLive()
+	defer t.runlock()
+	return t.size()
+}
 
+// Size() returns the size (number of Nodes) of the Tree
+func (t *UnsyncTree[K, V]) Size() int {
+	t.removeExpired()
+	return t.size()
+}
+
+func (t *core[K, V]) size() int {
+	return t.count
 }
 
 // Depth() returns the depth of the Tree (the maximum iteration for searching a Node)
 // Basically, it delegates the Size to its RootNode (or returns 0)
 func (t *Tree[K, V]) Depth() int {
-	t.rwMutex.RLock()
-	defer t.rwMutex.RUnlock()
+	t.rlockLive()
+	defer t.runlock()
+	return t.depth()
+}
 
+// Depth() returns the depth of the Tree (the maximum iteration for searching a Node)
+func (t *UnsyncTree[K, V]) Depth() int {
+	t.removeExpired()
+	return t.depth()
+}
+
+func (t *core[K, V]) depth() int {
 	if t.RootNode == nil {
 		return 0
 	}
@@ -87,10 +201,21 @@ func (t *Tree[K, V]) Depth() int {
 // Print() returns the ordered nodes in the tree
 // depth represents the depth in which print the elements (0 for all depths)
 func (t *Tree[K, V]) Print(depth uint) (nodes []*Node[K, V]) {
-	t.rwMutex.RLock()
-	defer t.rwMutex.RUnlock()
+	t.rlockLive()
+	defer t.runlock()
+	return t.print(depth)
+}
+
+// Print() returns the ordered nodes in the tree
+// depth represents the depth in which print the elements (0 for all depths)
+func (t *UnsyncTree[K, V]) Print(depth uint) (nodes []*Node[K, V]) {
+	t.removeExpired()
+	return t.print(depth)
+}
 
-	if t.RootNode == nil || depth > uint(t.Depth()) {
+func (t *core[K, V]) print(depth uint) (nodes []*Node[K, V]) {
+	//don't call t.Depth() here : taking the read lock twice could deadlock with a pending writer
+	if t.RootNode == nil || depth > uint(t.RootNode.Depth()) {
 		return nodes
 	}
 	return t.RootNode.Print(depth, 1)
@@ -98,17 +223,34 @@ func (t *Tree[K, V]) Print(depth uint) (nodes []*Node[K, V]) {
 
 // PrintKeys() act like Print but returns only the ordered array if keys in the tree
 func (t *Tree[K, V]) PrintKeys(depth uint) (keys []K) {
-	nodes := t.Print(depth)
+	return nodeKeys(t.Print(depth))
+}
+
+// PrintKeys() act like Print but returns only the ordered array if keys in the tree
+func (t *UnsyncTree[K, V]) PrintKeys(depth uint) (keys []K) {
+	return nodeKeys(t.Print(depth))
+}
+
+// PrintValues() act like Print but returns only the ordered array of values in the tree
+func (t *Tree[K, V]) PrintValues(depth uint) (values []V) {
+	return nodeValues(t.Print(depth))
+}
+
+// PrintValues() act like Print but returns only the ordered array of values in the tree
+func (t *UnsyncTree[K, V]) PrintValues(depth uint) (values []V) {
+	return nodeValues(t.Print(depth))
+}
+
+// nodeKeys() returns the keys of the nodes
+func nodeKeys[K Ordered, V any](nodes []*Node[K, V]) (keys []K) {
 	for _, n := range nodes {
 		keys = append(keys, n.Key)
 	}
 	return keys
 }
 
-// PrintValues() act like Print but returns only the ordered array of values in the tree
-func (t *Tree[K, V]) PrintValues(depth uint) (values []V) {
-	nodes := t.Print(depth)
-
+// nodeValues() returns the values of the nodes
+func nodeValues[K Ordered, V any](nodes []*Node[K, V]) (values []V) {
 	for _, n := range nodes {
 		values = append(values, n.Value)
 	}
@@ -118,17 +260,78 @@ func (t *Tree[K, V]) PrintValues(depth uint) (values []V) {
 // AddOne() add one element in the Tree[K,V]. It returns true if succeded
 // If the key K is already present, its value is replaced
 // Because adding an element can produce a re-balance of the tree, AddOne() will LOCK the tree
+// A TTL previously set on the key with PutWithTTL() is removed
+// On a bounded tree, it returns false if the key itself was evicted. A NaN key is not put : it returns false
 func (t *Tree[K, V]) PutOne(key K, value V) bool {
-	t.rwMutex.Lock()
-	defer t.rwMutex.Unlock()
+	if isNaN(key) {
+		return false
+	}
+	t.lock()
+	t.clearDeadline(key)
+	t.putNode(key, value)
+	changes := t.settle(nil)
+	t.unlock()
+
+	changes.notify()
+	return changes.kept(key)
+}
 
-	if t.RootNode == nil {
-		t.RootNode = &Node[K, V]{Key: key, Value: value}
-	} else {
-		newRoot := t.RootNode.Put(key, value)
-		t.RootNode = newRoot
+// PutOne() adds one element in the tree, replacing the value if the key is already present, like Tree.PutOne()
+func (t *UnsyncTree[K, V]) PutOne(key K, value V) bool {
+	if isNaN(key) {
+		return false
 	}
-	return true
+	t.clearDeadline(key)
+	t.putNode(key, value)
+	changes := t.settle(nil)
+
+	changes.notify()
+	return changes.kept(key)
+}
+
+// putNode() puts the key/value in the tree (the tree must be locked)
+func (t *core[K, V]) putNode(key K, value V) {
+	var node, parent *Node[K, V]
+	if t.RootNode != nil {
+		node, parent = t.RootNode.search(key, &t.probe)
+	}
+	t.storeAt(node, parent, key, value)
+}
+
+// storeAt() stores the value in node, or in a new child of parent if node is nil,
+// or in a new root node if both are nil (the tree must be locked)
+func (t *core[K, V]) storeAt(node, parent *Node[K, V], key K, value V) {
+	switch {
+	case node != nil:
+		node.Value = value
+		t.probe.trace(TraceReplace, key, node)
+	case parent != nil:
+		t.RootNode = parent.insertChild(key, value, &t.probe)
+	default:
+		t.RootNode = &Node[K, V]{Key: key, Value: value, depth: 1}
+		t.probe.trace(TraceInsertLeaf, key, t.RootNode)
+	}
+	if node == nil {
+		t.count++
+	}
+	t.bounds.use(key)
+	t.observers.queue(EventPut, key, value)
+}
+
+// removeNode() deletes the node from the tree and forgets its key
+// in the TTL and bounds bookkeeping (the tree must be locked)
+func (t *core[K, V]) removeNode(node *Node[K, V]) {
+	t.forgetNode(node)
+	t.RootNode = node.delete(&t.probe)
+}
+
+// forgetNode() forgets the key of a node removed from the tree in the TTL and bounds bookkeeping,
+// and queues its delete event (the tree must be locked)
+func (t *core[K, V]) forgetNode(node *Node[K, V]) {
+	t.count--
+	t.clearDeadline(node.Key)
+	t.bounds.remove(node.Key)
+	t.observers.queue(EventDelete, node.Key, node.Value)
 }
 
 // Add() adds elements `items` to the Node in a concurrent way
@@ -163,11 +366,33 @@ func (t *Tree[K, V]) Put(items ...struct {
 	return
 }
 
+// Put() adds elements `items` to the tree, one after the other
+func (t *UnsyncTree[K, V]) Put(items ...struct {
+	key   K
+	value V
+}) (addedItems int) {
+	for _, item := range items {
+		if t.PutOne(item.key, item.value) {
+			addedItems++
+		}
+	}
+	return
+}
+
 // GetFromTo() return an ordered slice of values for keys found between from and to (including bounds or not)
 func (t *Tree[K, V]) GetFromTo(from, to K, boundsIncluded bool) (values []V) {
-	t.rwMutex.RLock()
-	defer t.rwMutex.RUnlock()
+	t.rlockLive()
+	defer t.runlock()
+	return t.getFromTo(from, to, boundsIncluded)
+}
 
+// GetFromTo() return an ordered slice of values for keys found between from and to (including bounds or not)
+func (t *UnsyncTree[K, V]) GetFromTo(from, to K, boundsIncluded bool) (values []V) {
+	t.removeExpired()
+	return t.getFromTo(from, to, boundsIncluded)
+}
+
+func (t *core[K, V]) getFromTo(from, to K, boundsIncluded bool) (values []V) {
 	if t.RootNode == nil {
 		return
 	}
@@ -179,34 +404,218 @@ func (t *Tree[K, V]) GetFromTo(from, to K, boundsIncluded bool) (values []V) {
 	return
 }
 
+// Ascend() calls fn for each key/value of the tree, in order, until fn returns false
+// The tree is read-locked during the walk, so fn must not modify it
+func (t *Tree[K, V]) Ascend(fn func(key K, value V) bool) {
+	t.AscendRange(Unbounded[K](), Unbounded[K](), fn) //an unbounded range is valid
+}
+
+// Ascend() calls fn for each key/value of the tree, in order, until fn returns false
+// fn must not modify the tree
+func (t *UnsyncTree[K, V]) Ascend(fn func(key K, value V) bool) {
+	t.AscendRange(Unbounded[K](), Unbounded[K](), fn)
+}
+
+// AscendFromTo() acts like Ascend() for the keys between from and to (including bounds or not)
+func (t *Tree[K, V]) AscendFromTo(from, to K, boundsIncluded bool, fn func(key K, value V) bool) {
+	lo, hi := boundsOf(from, to, boundsIncluded)
+	t.rlockLive()
+	defer t.runlock()
+	t.ascend(lo, hi, fn)
+}
+
+// AscendFromTo() acts like Ascend() for the keys between from and to (including bounds or not)
+func (t *UnsyncTree[K, V]) AscendFromTo(from, to K, boundsIncluded bool, fn func(key K, value V) bool) {
+	lo, hi := boundsOf(from, to, boundsIncluded)
+	t.removeExpired()
+	t.ascend(lo, hi, fn)
+}
+
+// ascend() calls fn for each key/value between the bounds lo and hi, in order, until fn returns false
+func (t *core[K, V]) ascend(lo, hi Bound[K], fn func(key K, value V) bool) {
+	if t.RootNode != nil {
+		t.RootNode.ascend(lo, hi, func(node *Node[K, V]) bool {
+			return fn(node.Key, node.Value)
+		})
+	}
+}
+
+// read() calls fn with the core of the tree, read-locked, without expired entries
+func (t *Tree[K, V]) read(fn func(t *core[K, V])) {
+	t.rlockLive()
+	defer t.runlock()
+	fn(&t.core)
+}
+
+// read() calls fn with the core of the tree, without expired entries
+func (t *UnsyncTree[K, V]) read(fn func(t *core[K, V])) {
+	t.removeExpired()
+	fn(&t.core)
+}
+
+// entries() returns the ordered keys and values of the tree, read at once
+func entries[K Ordered, V any](t AnyTree[K, V]) (keys []K, values []V) {
+	t.read(func(t *core[K, V]) {
+		t.ascend(Unbounded[K](), Unbounded[K](), func(key K, value V) bool {
+			keys = append(keys, key)
+			values = append(values, value)
+			return true
+		})
+	})
+	return keys, values
+}
+
 // Get() returns the value present in the tree for the key
 func (t *Tree[K, V]) Get(key K) (value V, ok bool) {
-	t.rwMutex.RLock()
-	defer t.rwMutex.RUnlock()
+	if !t.bounds.tracksRecency() {
+		t.rlockLive()
+		defer t.runlock()
+		return t.get(key)
+	}
+	//the key becomes the most recently used : the get writes in the tree
+	t.lock()
+	changes := t.settle(t.popExpired())
+	value, ok = t.get(key)
+	t.unlock()
+
+	changes.notify()
+	return value, ok
+}
+
+// Get() returns the value present in the tree for the key
+func (t *UnsyncTree[K, V]) Get(key K) (value V, ok bool) {
+	t.removeExpired()
+	return t.get(key)
+}
+
+func (t *core[K, V]) get(key K) (value V, ok bool) {
 	if t.RootNode == nil {
 		return
 	}
 	if foundNode := t.RootNode.Get(key); foundNode != nil {
-		return t.RootNode.Get(key).Value, true
+		t.bounds.use(key)
+		return foundNode.Value, true
 	} else {
 		return
 	}
 }
 
+// Floor() returns the entry of the biggest key of the tree smaller or equal to key (ok is false if there is none)
+func (t *Tree[K, V]) Floor(key K) (floor K, value V, ok bool) {
+	t.rlockLive()
+	defer t.runlock()
+	return entryOf(t.RootNode.floor(key))
+}
+
+// Floor() returns the entry of the biggest key of the tree smaller or equal to key (ok is false if there is none)
+func (t *UnsyncTree[K, V]) Floor(key K) (floor K, value V, ok bool) {
+	t.removeExpired()
+	return entryOf(t.RootNode.floor(key))
+}
+
+// Ceiling() returns the entry of the smallest key of the tree bigger or equal to key (ok is false if there is none)
+func (t *Tree[K, V]) Ceiling(key K) (ceiling K, value V, ok bool) {
+	t.rlockLive()
+	defer t.runlock()
+	return entryOf(t.RootNode.ceiling(key))
+}
+
+// Ceiling() returns the entry of the smallest key of the tree bigger or equal to key (ok is false if there is none)
+func (t *UnsyncTree[K, V]) Ceiling(key K) (ceiling K, value V, ok bool) {
+	t.removeExpired()
+	return entryOf(t.RootNode.ceiling(key))
+}
+
+// entryOf() returns the key and the value of the node (ok is false for a nil node)
+func entryOf[K Ordered, V any](node *Node[K, V]) (key K, value V, ok bool) {
+	if node == nil {
+		return key, value, false
+	}
+	return node.Key, node.Value, true
+}
+
 // Delete() will remove the nodes corresponding to the passed keys
 // and returns the number of nodes deleted
 func (t *Tree[K, V]) Delete(keys ...K) int {
+	t.lock()
+	deleted := t.deleteKeys(keys)
+	changes := t.settle(nil)
+	t.unlock()
+
+	changes.notify()
+	return deleted
+}
+
+// Delete() will remove the nodes corresponding to the passed keys
+// and returns the number of nodes deleted
+func (t *UnsyncTree[K, V]) Delete(keys ...K) int {
+	deleted := t.deleteKeys(keys)
+	t.settle(nil).notify()
+	return deleted
+}
 
+// deleteKeys() removes the nodes corresponding to the keys (the tree must be locked)
+func (t *core[K, V]) deleteKeys(keys []K) int {
 	deleted := 0
 
 	for _, k := range keys {
-		if foundNode := t.RootNode.Get(k); foundNode != nil {
-			t.rwMutex.Lock()
-			t.RootNode = foundNode.Delete()
+		if t.RootNode == nil {
+			break
+		}
+		if isNaN(k) {
+			continue
+		}
+		if foundNode, _ := t.RootNode.search(k, &t.probe); foundNode != nil {
+			t.removeNode(foundNode)
 			deleted++
-			t.rwMutex.Unlock()
 		}
 	}
 
 	return deleted
 }
+
+// changes is what a write leaves to do once the tree is unlocked : delivering the queued events
+// and calling the callbacks of the evicted and expired entries. Nothing is called with the tree locked,
+// so the observers and the callbacks may use the tree
+type changes[K Ordered, V any] struct {
+	observers *observers[K, V]
+	evicted   []*Node[K, V]
+	onEvict   func(key K, value V)
+	expired   []*Node[K, V]
+	onExpire  func(key K, value V)
+}
+
+// settle() evicts the entries exceeding the capacity and returns the changes to notify,
+// with the expired nodes removed by the write (the tree must be locked)
+func (t *core[K, V]) settle(expired []*Node[K, V]) changes[K, V] {
+	c := changes[K, V]{observers: t.observers, evicted: t.evict(), expired: expired, onExpire: t.expiration.onExpire}
+	if t.bounds != nil {
+		c.onEvict = t.bounds.onEvict
+	}
+	return c
+}
+
+// notify() delivers the events and calls the callbacks (the tree must NOT be locked)
+func (c changes[K, V]) notify() {
+	c.observers.notify()
+	if c.onExpire != nil {
+		for _, node := range c.expired {
+			c.onExpire(node.Key, node.Value)
+		}
+	}
+	if c.onEvict != nil {
+		for _, node := range c.evicted {
+			c.onEvict(node.Key, node.Value)
+		}
+	}
+}
+
+// kept() returns false if the key was evicted
+func (c changes[K, V]) kept(key K) bool {
+	for _, node := range c.evicted {
+		if node.Key == key {
+			return false
+		}
+	}
+	return true
+}
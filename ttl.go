@@ -0,0 +1,248 @@
+package avlgo
+
+import (
+	"sync"
+	"time"
+)
+
+// expiration holds the expiration state of a Tree
+// Deadlines are stored in unix nanoseconds, in a map (key -> deadline) and in a secondary
+// index ordered by deadline (deadline -> keys) so the expired keys are found without scanning the Tree
+type expiration[K Ordered, V any] struct {
+	now        func() time.Time     //the clock used to expire keys (time.Now if nil)
+	deadlines  map[K]int64          //deadline of each key put with a TTL
+	index      *Node[int64, []K]    //root node of the expiry-ordered index
+	nextExpiry int64                //smallest deadline in the index (0 if none)
+	onExpire   func(key K, value V) //called for every expired entry
+}
+
+// SetClock() replaces the clock used to expire the keys (time.Now by default)
+// It is mainly useful for testing
+func (t *Tree[K, V]) SetClock(now func() time.Time) {
+	t.lock()
+	defer t.unlock()
+	t.expiration.now = now
+}
+
+// SetClock() replaces the clock used to expire the keys (time.Now by default)
+func (t *UnsyncTree[K, V]) SetClock(now func() time.Time) {
+	t.expiration.now = now
+}
+
+// OnExpire() registers a callback called (outside of the lock) for every entry removed because its TTL expired
+func (t *Tree[K, V]) OnExpire(fn func(key K, value V)) {
+	t.lock()
+	defer t.unlock()
+	t.expiration.onExpire = fn
+}
+
+// OnExpire() registers a callback called for every entry removed because its TTL expired
+func (t *UnsyncTree[K, V]) OnExpire(fn func(key K, value V)) {
+	t.expiration.onExpire = fn
+}
+
+// PutWithTTL() acts like PutOne() but the entry expires after ttl
+// Expired entries are never returned by Get(), GetFromTo(), Print() and are not counted by Size()
+// A non-positive ttl stores the entry without expiration, like PutOne()
+// The deadline is encoded by Encode() and restored by Decode()
+func (t *Tree[K, V]) PutWithTTL(key K, value V, ttl time.Duration) bool {
+	if isNaN(key) {
+		return false
+	}
+	t.lock()
+	t.putWithTTL(key, value, ttl)
+	changes := t.settle(nil)
+	t.unlock()
+
+	changes.notify()
+	return changes.kept(key)
+}
+
+// PutWithTTL() acts like PutOne() but the entry expires after ttl, like Tree.PutWithTTL()
+func (t *UnsyncTree[K, V]) PutWithTTL(key K, value V, ttl time.Duration) bool {
+	if isNaN(key) {
+		return false
+	}
+	t.putWithTTL(key, value, ttl)
+	changes := t.settle(nil)
+
+	changes.notify()
+	return changes.kept(key)
+}
+
+// putWithTTL() puts the key/value with its deadline (the tree must be locked)
+func (t *core[K, V]) putWithTTL(key K, value V, ttl time.Duration) {
+	t.clearDeadline(key)
+	t.putNode(key, value)
+	if ttl > 0 {
+		t.setDeadline(key, t.clock().Add(ttl).UnixNano())
+	}
+}
+
+// TTL() returns the remaining time to live of the key
+// ok is false if the key is not present or has no TTL
+func (t *Tree[K, V]) TTL(key K) (remaining time.Duration, ok bool) {
+	t.rlockLive()
+	defer t.runlock()
+	return t.ttl(key)
+}
+
+// TTL() returns the remaining time to live of the key
+// ok is false if the key is not present or has no TTL
+func (t *UnsyncTree[K, V]) TTL(key K) (remaining time.Duration, ok bool) {
+	t.removeExpired()
+	return t.ttl(key)
+}
+
+func (t *core[K, V]) ttl(key K) (remaining time.Duration, ok bool) {
+	deadline, ok := t.expiration.deadlines[key]
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(deadline - t.clock().UnixNano()), true
+}
+
+// RemoveExpired() removes every expired entry and returns the number of removed entries
+// The OnExpire() callback is called for each of them
+func (t *Tree[K, V]) RemoveExpired() int {
+	t.lock()
+	changes := t.settle(t.popExpired())
+	t.unlock()
+
+	//the callback is called without the lock so it can use the tree
+	changes.notify()
+	return len(changes.expired)
+}
+
+// RemoveExpired() removes every expired entry and returns the number of removed entries
+// The OnExpire() callback is called for each of them
+func (t *UnsyncTree[K, V]) RemoveExpired() int {
+	changes := t.settle(t.popExpired())
+	changes.notify()
+	return len(changes.expired)
+}
+
+// StartJanitor() starts a goroutine removing the expired entries every interval
+// Call the returned stop() function to stop the janitor
+func (t *Tree[K, V]) StartJanitor(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				t.RemoveExpired()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// rlockLive() read-locks the tree once its expired entries are removed : it is the lazy expiration
+// done by the reading methods. It costs nothing more than the read lock when no entry has expired
+func (t *Tree[K, V]) rlockLive() {
+	t.rlock()
+	for t.expired() {
+		t.runlock()
+		t.RemoveExpired()
+		t.rlock()
+	}
+}
+
+// removeExpired() is the lazy expiration done by the reading methods
+func (t *UnsyncTree[K, V]) removeExpired() {
+	if t.expired() {
+		t.RemoveExpired()
+	}
+}
+
+// expired() returns true if an entry has expired (the tree must be locked)
+func (t *core[K, V]) expired() bool {
+	next := t.expiration.nextExpiry
+	return next != 0 && next <= t.clock().UnixNano()
+}
+
+// clock() returns the current time of the tree clock
+func (t *core[K, V]) clock() time.Time {
+	if t.expiration.now == nil {
+		return time.Now()
+	}
+	return t.expiration.now()
+}
+
+// setDeadline() records the deadline of a key in the map and in the index (the tree must be locked)
+func (t *core[K, V]) setDeadline(key K, deadline int64) {
+	if t.expiration.deadlines == nil {
+		t.expiration.deadlines = make(map[K]int64)
+	}
+	t.expiration.deadlines[key] = deadline
+
+	if t.expiration.index == nil {
+		t.expiration.index = &Node[int64, []K]{Key: deadline, Value: []K{key}}
+	} else if node := t.expiration.index.Get(deadline); node != nil {
+		node.Value = append(node.Value, key)
+	} else {
+		t.expiration.index = t.expiration.index.Put(deadline, []K{key})
+	}
+	t.updateNextExpiry()
+}
+
+// clearDeadline() removes the deadline of a key, if any (the tree must be locked)
+func (t *core[K, V]) clearDeadline(key K) {
+	deadline, ok := t.expiration.deadlines[key]
+	if !ok {
+		return
+	}
+	delete(t.expiration.deadlines, key)
+
+	node := t.expiration.index.Get(deadline)
+	for i, k := range node.Value {
+		if k == key {
+			node.Value = append(node.Value[:i], node.Value[i+1:]...)
+			break
+		}
+	}
+	if len(node.Value) == 0 {
+		t.expiration.index = node.Delete()
+	}
+	t.updateNextExpiry()
+}
+
+// popExpired() removes from the tree the entries whose deadline is passed
+// and returns their nodes (the tree must be locked)
+func (t *core[K, V]) popExpired() (expired []*Node[K, V]) {
+	if !t.expired() {
+		return nil
+	}
+	now := t.clock().UnixNano()
+	for t.expiration.index != nil {
+		first := t.expiration.index.min()
+		if first.Key > now {
+			break
+		}
+		//removeNode() clears the deadlines, so the index node is emptied (and deleted) along the way
+		for _, k := range append([]K(nil), first.Value...) {
+			node := t.RootNode.Get(k)
+			expired = append(expired, node)
+			t.removeNode(node)
+		}
+	}
+	return expired
+}
+
+// updateNextExpiry() records the smallest deadline of the index (the tree must be locked)
+func (t *core[K, V]) updateNextExpiry() {
+	if t.expiration.index == nil {
+		t.expiration.nextExpiry = 0
+	} else {
+		t.expiration.nextExpiry = t.expiration.index.min().Key
+	}
+}
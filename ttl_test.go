@@ -0,0 +1,191 @@
+package avlgo
+
+import (
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for testing expiration
+type fakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestPutWithTTL(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		clock := &fakeClock{now: time.Unix(1000, 0)}
+		tree := newVariantTree[int, string](v)
+		tree.SetClock(clock.Now)
+
+		tree.PutOne(1, "a")
+		tree.PutWithTTL(2, "b", time.Second)
+		tree.PutWithTTL(3, "c", 2*time.Second)
+		tree.PutWithTTL(4, "d", 2*time.Second)
+
+		if tree.Size() != 4 {
+			t.Errorf("Tree size is %d, want 4", tree.Size())
+		}
+		if remaining, ok := tree.TTL(2); !ok || remaining != time.Second {
+			t.Errorf("TTL(2) returns %v, %v, want 1s, true", remaining, ok)
+		}
+		if _, ok := tree.TTL(1); ok {
+			t.Errorf("TTL(1) shouldn't find a TTL")
+		}
+
+		clock.Advance(time.Second)
+		if _, ok := tree.Get(2); ok {
+			t.Errorf("Get shouldn't find the expired key 2")
+		}
+		if value, ok := tree.Get(3); !ok || value != "c" {
+			t.Errorf("Get returns %s, %v, want c, true", value, ok)
+		}
+		if tree.Size() != 3 {
+			t.Errorf("Tree size is %d, want 3", tree.Size())
+		}
+
+		clock.Advance(time.Second)
+		values := tree.GetFromTo(0, 10, true)
+		if !reflect.DeepEqual(values, []string{"a"}) {
+			t.Errorf("values is %v, want %v", values, []string{"a"})
+		}
+		keys := tree.PrintKeys(0)
+		if !reflect.DeepEqual(keys, []int{1}) {
+			t.Errorf("keys is %v, want %v", keys, []int{1})
+		}
+	})
+}
+
+func TestPutOneAndDeleteClearTTL(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		clock := &fakeClock{now: time.Unix(1000, 0)}
+		tree := newVariantTree[int, int](v)
+		tree.SetClock(clock.Now)
+
+		tree.PutWithTTL(1, 1, time.Second)
+		tree.PutWithTTL(2, 2, time.Second)
+		tree.PutWithTTL(3, 3, time.Second)
+		tree.PutOne(1, 10)                    //1 doesn't expire anymore
+		tree.Delete(2)                        //2 is gone with its TTL
+		tree.PutOne(2, 20)                    //so a new 2 doesn't expire
+		tree.PutWithTTL(3, 30, 3*time.Second) //3 is refreshed
+
+		clock.Advance(2 * time.Second)
+		if tree.RemoveExpired() != 0 {
+			t.Errorf("RemoveExpired() shouldn't remove anything")
+		}
+		values := tree.PrintValues(0)
+		if !reflect.DeepEqual(values, []int{10, 20, 30}) {
+			t.Errorf("values is %v, want %v", values, []int{10, 20, 30})
+		}
+
+		clock.Advance(time.Second)
+		if removed := tree.RemoveExpired(); removed != 1 {
+			t.Errorf("RemoveExpired() removes %d entries, want 1", removed)
+		}
+		if len(coreOf(tree).expiration.deadlines) != 0 || coreOf(tree).expiration.index != nil || coreOf(tree).expiration.nextExpiry != 0 {
+			t.Errorf("the expiration index should be empty")
+		}
+	})
+}
+
+func TestOnExpire(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		clock := &fakeClock{now: time.Unix(1000, 0)}
+		tree := newVariantTree[int, int](v)
+		tree.SetClock(clock.Now)
+
+		expired := make([]int, 0)
+		tree.OnExpire(func(key, value int) {
+			//the callback can use the tree
+			if _, ok := tree.Get(key); ok {
+				t.Errorf("Get shouldn't find the expired key %d", key)
+			}
+			expired = append(expired, key)
+		})
+		for i := 0; i < 10; i++ {
+			tree.PutWithTTL(i, i, time.Duration(10-i)*time.Second)
+		}
+
+		clock.Advance(5 * time.Second)
+		tree.Size()
+		if !reflect.DeepEqual(expired, []int{9, 8, 7, 6, 5}) {
+			t.Errorf("expired keys are %v, want %v", expired, []int{9, 8, 7, 6, 5})
+		}
+	})
+}
+
+func TestEncodeWithTTL(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		//the decoded tree uses the real clock : the fake one starts now
+		clock := &fakeClock{now: time.Now()}
+		tree := newVariantTree[int, int](v)
+		tree.SetClock(clock.Now)
+
+		tree.PutOne(1, 1)
+		tree.PutWithTTL(2, 2, time.Second)
+		tree.PutWithTTL(3, 3, time.Hour)
+		clock.Advance(2 * time.Second)
+
+		file := filepath.Join(t.TempDir(), "tree.gob")
+		if err := tree.Encode(file); err != nil {
+			t.Fatalf("Encode() fails : %s", err)
+		}
+		decoded, err := Decode[int, int](file)
+		if err != nil {
+			t.Fatalf("Decode() fails : %s", err)
+		}
+
+		if keys := decoded.PrintKeys(0); !reflect.DeepEqual(keys, []int{1, 3}) {
+			t.Errorf("keys is %v, want %v", keys, []int{1, 3})
+		}
+		if remaining, ok := decoded.TTL(3); !ok || remaining <= 0 || remaining > time.Hour {
+			t.Errorf("TTL(3) returns %v, %v, want at most 1h, true", remaining, ok)
+		}
+		if _, ok := decoded.TTL(1); ok {
+			t.Errorf("TTL(1) shouldn't find a TTL")
+		}
+	})
+}
+
+func TestJanitor(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	tree := NewTree[int, int]()
+	tree.SetClock(clock.Now)
+
+	expired := make(chan int, 1)
+	tree.OnExpire(func(key, value int) {
+		expired <- key
+	})
+	tree.PutWithTTL(1, 1, time.Second)
+	tree.PutOne(2, 2)
+
+	stop := tree.StartJanitor(time.Millisecond)
+	defer stop()
+
+	clock.Advance(time.Second)
+	select {
+	case key := <-expired:
+		if key != 1 {
+			t.Errorf("expired key is %d, want 1", key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the janitor didn't remove the expired key")
+	}
+	stop()
+	stop()
+}
@@ -0,0 +1,147 @@
+package avlgo
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// TupleKey is a composite key : the order-preserving encoding of the fields of a tuple
+// Comparing two TupleKeys compares their tuples field by field (lexicographic order),
+// and the key of a tuple is a prefix of the keys of the longer tuples starting with the same fields,
+// so WithPrefix() finds every key sharing the leading fields
+//
+// Each field is encoded in a self-delimiting way :
+// - integers on 8 big-endian bytes, with the sign bit flipped for signed integers
+// - floats on 8 big-endian bytes : the sign bit is flipped for positive floats, every bit for negative ones (-0 is encoded as +0)
+// - strings with their 0x00 bytes escaped as 0x00 0xff, terminated by 0x00 0x01
+type TupleKey string
+
+// Tuple2 is a key made of 2 fields, ordered by First then Second
+type Tuple2[A, B Ordered] struct {
+	First  A
+	Second B
+}
+
+// Tuple3 is a key made of 3 fields, ordered by First, Second then Third
+type Tuple3[A, B, C Ordered] struct {
+	First  A
+	Second B
+	Third  C
+}
+
+// Key() returns the TupleKey of the tuple
+func (t Tuple2[A, B]) Key() TupleKey {
+	return encodeTuple(t.First, t.Second)
+}
+
+// Key() returns the TupleKey of the tuple
+func (t Tuple3[A, B, C]) Key() TupleKey {
+	return encodeTuple(t.First, t.Second, t.Third)
+}
+
+// TuplePrefix() returns the key prefix of all the tuples whose first field is first
+// (use Tuple2.Key() as the prefix of the Tuple3 sharing their 2 first fields)
+func TuplePrefix[A Ordered](first A) TupleKey {
+	return encodeTuple(first)
+}
+
+// ParseTuple2() decodes a TupleKey built by Tuple2.Key()
+func ParseTuple2[A, B Ordered](key TupleKey) (t Tuple2[A, B], err error) {
+	err = decodeTuple(key, &t.First, &t.Second)
+	return t, err
+}
+
+// ParseTuple3() decodes a TupleKey built by Tuple3.Key()
+func ParseTuple3[A, B, C Ordered](key TupleKey) (t Tuple3[A, B, C], err error) {
+	err = decodeTuple(key, &t.First, &t.Second, &t.Third)
+	return t, err
+}
+
+// encodeTuple() concatenates the encodings of the fields
+func encodeTuple(fields ...any) TupleKey {
+	b := make([]byte, 0, 8*len(fields))
+	for _, field := range fields {
+		v := reflect.ValueOf(field)
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			b = binary.BigEndian.AppendUint64(b, uint64(v.Int())^(1<<63))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			b = binary.BigEndian.AppendUint64(b, v.Uint())
+		case reflect.Float32, reflect.Float64:
+			f := v.Float()
+			if f == 0 {
+				f = 0 //-0 == +0, so they have the same encoding
+			}
+			bits := math.Float64bits(f)
+			if bits&(1<<63) != 0 {
+				bits = ^bits
+			} else {
+				bits ^= 1 << 63
+			}
+			b = binary.BigEndian.AppendUint64(b, bits)
+		default:
+			for _, c := range []byte(v.String()) {
+				b = append(b, c)
+				if c == 0x00 {
+					b = append(b, 0xff)
+				}
+			}
+			b = append(b, 0x00, 0x01)
+		}
+	}
+	return TupleKey(b)
+}
+
+// decodeTuple() decodes the key in the fields (pointers to Ordered values)
+func decodeTuple(key TupleKey, fields ...any) error {
+	b := []byte(key)
+	for i, field := range fields {
+		v := reflect.ValueOf(field).Elem()
+		switch v.Kind() {
+		case reflect.String:
+			s := make([]byte, 0)
+		readString:
+			for {
+				switch {
+				case len(b) == 0 || (b[0] == 0x00 && len(b) < 2):
+					return fmt.Errorf("unable to decode tuple : field %d is truncated", i)
+				case b[0] != 0x00:
+					s, b = append(s, b[0]), b[1:]
+				case b[1] == 0xff: //escaped 0x00
+					s, b = append(s, 0x00), b[2:]
+				case b[1] == 0x01: //end of the string
+					b = b[2:]
+					break readString
+				default:
+					return fmt.Errorf("unable to decode tuple : field %d has an invalid escape", i)
+				}
+			}
+			v.SetString(string(s))
+		default:
+			if len(b) < 8 {
+				return fmt.Errorf("unable to decode tuple : field %d is truncated", i)
+			}
+			bits := binary.BigEndian.Uint64(b)
+			b = b[8:]
+			switch v.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				v.SetInt(int64(bits ^ (1 << 63)))
+			case reflect.Float32, reflect.Float64:
+				if bits&(1<<63) != 0 {
+					bits ^= 1 << 63
+				} else {
+					bits = ^bits
+				}
+				v.SetFloat(math.Float64frombits(bits))
+			default:
+				v.SetUint(bits)
+			}
+		}
+	}
+	if len(b) != 0 {
+		return fmt.Errorf("unable to decode tuple : %d bytes left", len(b))
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package avlgo
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestTupleKeyOrder(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	strings := []string{"", "a", "a\x00", "a\x00b", "a\x01", "ab", "b", "\xff", "\x00"}
+	floats := []float64{math.Inf(-1), -2.5, -1, math.Copysign(0, -1), 0, 1e-300, 1, 2.5, math.Inf(1)}
+	newTuple := func() Tuple3[int32, string, float64] {
+		return Tuple3[int32, string, float64]{
+			First:  int32(random.Intn(5) - 2),
+			Second: strings[random.Intn(len(strings))],
+			Third:  floats[random.Intn(len(floats))],
+		}
+	}
+	less := func(a, b Tuple3[int32, string, float64]) bool {
+		if a.First != b.First {
+			return a.First < b.First
+		}
+		if a.Second != b.Second {
+			return a.Second < b.Second
+		}
+		return a.Third < b.Third
+	}
+
+	for i := 0; i < 5000; i++ {
+		a, b := newTuple(), newTuple()
+		if less(a, b) != (a.Key() < b.Key()) {
+			t.Fatalf("%v < %v is %v, but their keys say %v", a, b, less(a, b), a.Key() < b.Key())
+		}
+		decoded, err := ParseTuple3[int32, string, float64](a.Key())
+		if err != nil {
+			t.Fatalf("ParseTuple3 shouldn't return an error. %s is returned", err)
+		}
+		if decoded != a {
+			t.Fatalf("ParseTuple3 returns %v, want %v", decoded, a)
+		}
+	}
+}
+
+func TestTupleKeyErrors(t *testing.T) {
+	key := Tuple2[string, uint8]{First: "a", Second: 1}.Key()
+	if _, err := ParseTuple2[string, uint8](key); err != nil {
+		t.Errorf("ParseTuple2 shouldn't return an error. %s is returned", err)
+	}
+	for _, bad := range []TupleKey{key[:len(key)-1], key + "x", "a\x00", "a\x00\x02" + key[3:], ""} {
+		if _, err := ParseTuple2[string, uint8](bad); err == nil {
+			t.Errorf("ParseTuple2(%q) should return an error", bad)
+		}
+	}
+}
+
+func TestTupleKeysInTree(t *testing.T) {
+	type event = Tuple3[string, int64, string]
+	tree := NewTree[TupleKey, int]()
+	events := []event{
+		{"acme", 20, "b"}, {"acme", 10, "a"}, {"acme", 10, "c"}, {"acme", -5, "z"},
+		{"acme2", 1, "a"}, {"ac", 1, "a"}, {"globex", 10, "a"},
+	}
+	for i, e := range events {
+		tree.PutOne(e.Key(), i)
+	}
+
+	//every event of one tenant
+	found := make([]event, 0)
+	WithPrefix[TupleKey, int](tree, TuplePrefix("acme"), func(key TupleKey, value int) bool {
+		e, _ := ParseTuple3[string, int64, string](key)
+		found = append(found, e)
+		return true
+	})
+	wanted := []event{{"acme", -5, "z"}, {"acme", 10, "a"}, {"acme", 10, "c"}, {"acme", 20, "b"}}
+	if !reflect.DeepEqual(found, wanted) {
+		t.Errorf("events are %v, want %v", found, wanted)
+	}
+
+	//events of one tenant at one timestamp
+	if count := CountPrefix[TupleKey, int](tree, Tuple2[string, int64]{"acme", 10}.Key()); count != 2 {
+		t.Errorf("CountPrefix returns %d, want 2", count)
+	}
+
+	//events of one tenant between two timestamps
+	values := tree.GetFromTo(Tuple2[string, int64]{"acme", 0}.Key(), Tuple2[string, int64]{"acme", 20}.Key(), true)
+	if !reflect.DeepEqual(values, []int{1, 2}) {
+		t.Errorf("values is %v, want %v", values, []int{1, 2})
+	}
+}
@@ -0,0 +1,120 @@
+package avlgo
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// testTree is the API shared by Tree and UnsyncTree : the tests of the Tree run against both variants
+type testTree[K Ordered, V any] interface {
+	SortedMap[K, V]
+	Depth() int
+	Print(depth uint) []*Node[K, V]
+	PrintKeys(depth uint) []K
+	PrintValues(depth uint) []V
+	Put(items ...struct {
+		key   K
+		value V
+	}) int
+	Encode(output string) error
+	Floor(key K) (K, V, bool)
+	Ceiling(key K) (K, V, bool)
+
+	SetClock(now func() time.Time)
+	OnExpire(fn func(key K, value V))
+	PutWithTTL(key K, value V, ttl time.Duration) bool
+	TTL(key K) (time.Duration, bool)
+	RemoveExpired() int
+	OnEvict(fn func(key K, value V))
+	OnPut(fn func(key K, value V))
+	OnDelete(fn func(key K, value V))
+	Watch(from, to K, options WatchOptions) (<-chan Event[K, V], func())
+	Stats() Stats
+	SetTracer(tracer Tracer[K, V])
+
+	Compute(key K, fn func(old V, ok bool) (V, Op)) (V, bool)
+	GetOrPut(key K, value V) (V, bool)
+	PutIfAbsent(key K, value V) bool
+	Update(key K, fn func(old V) V) (V, bool)
+	CompareAndSwap(key K, old, value V, eq func(a, b V) bool) bool
+	CompareAndDelete(key K, old V, eq func(a, b V) bool) bool
+	Batch(ops ...BatchOp[K, V]) (int, int, error)
+
+	GetRange(lo, hi Bound[K]) ([]Entry[K, V], error)
+	CountRange(lo, hi Bound[K]) (int, error)
+	AscendRange(lo, hi Bound[K], fn func(key K, value V) bool) error
+	GetRangePage(lo, hi Bound[K], limit int, cursor string) ([]Entry[K, V], string, error)
+	GetFromToPage(from, to K, boundsIncluded bool, limit int, cursor string) ([]Entry[K, V], string, error)
+	DeleteRange(lo, hi Bound[K]) (int, error)
+	DeleteFunc(pred func(key K, value V) bool) int
+	Retain(pred func(key K, value V) bool) int
+}
+
+// variant is the kind of tree a test runs against
+type variant string
+
+const (
+	syncVariant   variant = "Tree"
+	unsyncVariant variant = "UnsyncTree"
+)
+
+// forEachVariant() runs the test once for each variant, as a subtest
+func forEachVariant(t *testing.T, test func(t *testing.T, v variant)) {
+	for _, v := range []variant{syncVariant, unsyncVariant} {
+		t.Run(string(v), func(t *testing.T) {
+			test(t, v)
+		})
+	}
+}
+
+// newVariantTree() returns an empty new tree of the variant
+func newVariantTree[K Ordered, V any](v variant) testTree[K, V] {
+	if v == unsyncVariant {
+		return NewUnsyncTree[K, V]()
+	}
+	return NewTree[K, V]()
+}
+
+// newVariantBoundedTree() returns an empty new bounded tree of the variant
+func newVariantBoundedTree[K Ordered, V any](v variant, maxEntries int, policy EvictionPolicy) testTree[K, V] {
+	if v == unsyncVariant {
+		return NewUnsyncBoundedTree[K, V](maxEntries, policy)
+	}
+	return NewBoundedTree[K, V](maxEntries, policy)
+}
+
+// repeat() calls fn n times : from n goroutines for a Tree, one after the other for an UnsyncTree
+func repeat(v variant, n int, fn func()) {
+	if v == unsyncVariant {
+		for i := 0; i < n; i++ {
+			fn()
+		}
+		return
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
+	wg.Wait()
+}
+
+// coreOf() returns the core of the tree, to check its internal state
+func coreOf[K Ordered, V any](tree testTree[K, V]) *core[K, V] {
+	switch tree := tree.(type) {
+	case *Tree[K, V]:
+		return &tree.core
+	case *UnsyncTree[K, V]:
+		return &tree.core
+	}
+	panic("unknown tree variant")
+}
+
+// rootOf() returns the root node of the tree
+func rootOf[K Ordered, V any](tree testTree[K, V]) *Node[K, V] {
+	return coreOf(tree).RootNode
+}
@@ -0,0 +1,270 @@
+package avlgo
+
+import "sync"
+
+// EventType is the kind of change notified by a Tree
+type EventType int
+
+const (
+	EventPut    EventType = iota // a key was added or its value replaced
+	EventDelete                  // a key was removed (deleted, expired or evicted)
+)
+
+// Event is a change of the Tree notified to the observers
+type Event[K Ordered, V any] struct {
+	Type  EventType
+	Key   K
+	Value V // the new value for EventPut, the removed value for EventDelete
+}
+
+// BackpressurePolicy tells what to do when the buffer of a watcher is full
+type BackpressurePolicy int
+
+const (
+	BackpressureBlock      BackpressurePolicy = iota // wait for the watcher to receive the event
+	BackpressureDropNewest                           // drop the event which doesn't fit in the buffer
+	BackpressureDropOldest                           // drop the oldest buffered event to make room
+	BackpressureClose                                // close the channel of the watcher (it is cancelled)
+)
+
+// WatchOptions configures a watcher. The zero value is an unbuffered blocking watcher
+type WatchOptions struct {
+	Buffer       int                // size of the events channel
+	Backpressure BackpressurePolicy // what to do when the channel is full
+}
+
+// observers holds the callbacks and watchers of a Tree and the queue of events to deliver
+// Events are queued while the tree is locked (so in the order of the changes), then delivered
+// by one writer at a time, without the lock : observers may use the tree, even for writing
+// A tree has no observers until one is registered, so the writers skip them otherwise
+type observers[K Ordered, V any] struct {
+	mutex           sync.Mutex    //protects the fields below
+	events          []Event[K, V] //events waiting to be delivered
+	dispatching     bool          //true while a writer delivers the queue
+	putCallbacks    []func(key K, value V)
+	deleteCallbacks []func(key K, value V)
+	watchers        []*watcher[K, V]
+}
+
+// watcher is a subscription to the changes of a range of keys
+type watcher[K Ordered, V any] struct {
+	from, to K
+	policy   BackpressurePolicy
+	events   chan Event[K, V]
+	done     chan struct{} //closed on cancellation, to release a blocked delivery
+	mutex    sync.Mutex    //protects closed and the closing of events
+	closed   bool
+	once     sync.Once
+}
+
+// OnPut() registers a callback called for every key put in the tree
+// Callbacks are called in the order of the changes, without holding the lock of the tree
+func (t *Tree[K, V]) OnPut(fn func(key K, value V)) {
+	t.lock()
+	defer t.unlock()
+	t.observe().onPut(fn)
+}
+
+// OnPut() registers a callback called for every key put in the tree, in the order of the changes
+func (t *UnsyncTree[K, V]) OnPut(fn func(key K, value V)) {
+	t.observe().onPut(fn)
+}
+
+// OnDelete() registers a callback called for every key removed from the tree
+// (deleted, expired or evicted) with its last value
+func (t *Tree[K, V]) OnDelete(fn func(key K, value V)) {
+	t.lock()
+	defer t.unlock()
+	t.observe().onDelete(fn)
+}
+
+// OnDelete() registers a callback called for every key removed from the tree
+// (deleted, expired or evicted) with its last value
+func (t *UnsyncTree[K, V]) OnDelete(fn func(key K, value V)) {
+	t.observe().onDelete(fn)
+}
+
+// Watch() returns a channel receiving, in order, the changes of the keys between from and to (bounds included)
+// Call cancel() to stop watching : the channel is then closed
+func (t *Tree[K, V]) Watch(from, to K, options WatchOptions) (events <-chan Event[K, V], cancel func()) {
+	t.lock()
+	defer t.unlock()
+	return t.observe().watch(from, to, options)
+}
+
+// Watch() returns a channel receiving, in order, the changes of the keys between from and to (bounds included)
+// Call cancel() to stop watching : the channel is then closed
+// The tree is used by only one goroutine : the buffer of the channel must hold the events of a change,
+// unless another goroutine receives them or the backpressure policy drops them
+func (t *UnsyncTree[K, V]) Watch(from, to K, options WatchOptions) (events <-chan Event[K, V], cancel func()) {
+	return t.observe().watch(from, to, options)
+}
+
+// observe() returns the observers of the tree, created on the first registration (the tree must be locked)
+func (t *core[K, V]) observe() *observers[K, V] {
+	if t.observers == nil {
+		t.observers = &observers[K, V]{}
+	}
+	return t.observers
+}
+
+// onPut() adds a put callback
+func (o *observers[K, V]) onPut(fn func(key K, value V)) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.putCallbacks = append(o.putCallbacks, fn)
+}
+
+// onDelete() adds a delete callback
+func (o *observers[K, V]) onDelete(fn func(key K, value V)) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.deleteCallbacks = append(o.deleteCallbacks, fn)
+}
+
+// watch() adds a watcher of the keys between from and to
+func (o *observers[K, V]) watch(from, to K, options WatchOptions) (events <-chan Event[K, V], cancel func()) {
+	w := &watcher[K, V]{
+		from:   from,
+		to:     to,
+		policy: options.Backpressure,
+		events: make(chan Event[K, V], options.Buffer),
+		done:   make(chan struct{}),
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.watchers = append(o.watchers, w)
+
+	return w.events, func() { o.unwatch(w) }
+}
+
+// unwatch() removes the watcher and closes its channel
+func (o *observers[K, V]) unwatch(w *watcher[K, V]) {
+	o.mutex.Lock()
+	//build a new slice : a delivery may be iterating over the old one
+	watchers := make([]*watcher[K, V], 0, len(o.watchers))
+	for _, other := range o.watchers {
+		if other != w {
+			watchers = append(watchers, other)
+		}
+	}
+	o.watchers = watchers
+	o.mutex.Unlock()
+
+	w.close()
+}
+
+// queue() queues an event if someone observes the tree (the tree must be locked, o may be nil)
+func (o *observers[K, V]) queue(eventType EventType, key K, value V) {
+	if o == nil {
+		return
+	}
+	o.mutex.Lock()
+	o.events = append(o.events, Event[K, V]{Type: eventType, Key: key, Value: value})
+	o.mutex.Unlock()
+}
+
+// notify() delivers the queued events (the tree must NOT be locked, o may be nil)
+// If another writer is already delivering, it will deliver our events too
+func (o *observers[K, V]) notify() {
+	if o == nil {
+		return
+	}
+	o.mutex.Lock()
+	if o.dispatching || len(o.events) == 0 {
+		o.mutex.Unlock()
+		return
+	}
+	o.dispatching = true
+
+	for len(o.events) > 0 {
+		events, onPut, onDelete, watchers := o.events, o.putCallbacks, o.deleteCallbacks, o.watchers
+		o.events = nil
+		o.mutex.Unlock()
+
+		closed := make([]*watcher[K, V], 0)
+		for _, event := range events {
+			callbacks := onPut
+			if event.Type == EventDelete {
+				callbacks = onDelete
+			}
+			for _, fn := range callbacks {
+				fn(event.Key, event.Value)
+			}
+			for _, w := range watchers {
+				if event.Key >= w.from && event.Key <= w.to && w.send(event) {
+					closed = append(closed, w)
+				}
+			}
+		}
+		//the watchers closed by their backpressure policy are cancelled
+		for _, w := range closed {
+			o.unwatch(w)
+		}
+
+		o.mutex.Lock()
+	}
+
+	o.dispatching = false
+	o.mutex.Unlock()
+}
+
+// send() delivers an event to the watcher, applying its backpressure policy
+// It returns true if the policy closed the watcher
+func (w *watcher[K, V]) send(event Event[K, V]) (closed bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.closed {
+		return false
+	}
+
+	switch w.policy {
+	case BackpressureDropNewest:
+		select {
+		case w.events <- event:
+		default:
+		}
+	case BackpressureDropOldest:
+		select {
+		case w.events <- event:
+		default:
+			//make room by dropping the oldest event (unless the watcher just received it)
+			select {
+			case <-w.events:
+			default:
+			}
+			select {
+			case w.events <- event:
+			default:
+			}
+		}
+	case BackpressureClose:
+		select {
+		case w.events <- event:
+		default:
+			w.closed = true
+			close(w.events)
+			return true
+		}
+	default:
+		select {
+		case w.events <- event:
+		case <-w.done:
+		}
+	}
+	return false
+}
+
+// close() closes the channel of the watcher (once)
+func (w *watcher[K, V]) close() {
+	w.once.Do(func() {
+		close(w.done) //release a blocked send() before taking the mutex
+		w.mutex.Lock()
+		defer w.mutex.Unlock()
+		if !w.closed {
+			w.closed = true
+			close(w.events)
+		}
+	})
+}
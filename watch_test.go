@@ -0,0 +1,153 @@
+package avlgo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOnPutAndOnDelete(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantTree[int, int](v)
+		events := make([]Event[int, int], 0)
+		tree.OnPut(func(key, value int) {
+			events = append(events, Event[int, int]{Type: EventPut, Key: key, Value: value})
+		})
+		tree.OnDelete(func(key, value int) {
+			events = append(events, Event[int, int]{Type: EventDelete, Key: key, Value: value})
+			//observers may write in the tree : the change is notified after the current one
+			if key == 2 {
+				tree.PutOne(20, 20)
+			}
+		})
+
+		tree.PutOne(1, 1)
+		tree.PutOne(2, 2)
+		tree.PutOne(1, 10)
+		tree.Delete(2, 3)
+
+		wanted := []Event[int, int]{
+			{Type: EventPut, Key: 1, Value: 1},
+			{Type: EventPut, Key: 2, Value: 2},
+			{Type: EventPut, Key: 1, Value: 10},
+			{Type: EventDelete, Key: 2, Value: 2},
+			{Type: EventPut, Key: 20, Value: 20},
+		}
+		if !reflect.DeepEqual(events, wanted) {
+			t.Errorf("events are %v, want %v", events, wanted)
+		}
+	})
+}
+
+func TestOnDeleteWithEviction(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantBoundedTree[int, int](v, 2, EvictSmallest)
+		deleted := make([]int, 0)
+		tree.OnDelete(func(key, value int) {
+			deleted = append(deleted, key)
+		})
+		for i := 0; i < 5; i++ {
+			tree.PutOne(i, i)
+		}
+		if !reflect.DeepEqual(deleted, []int{0, 1, 2}) {
+			t.Errorf("deleted keys are %v, want %v", deleted, []int{0, 1, 2})
+		}
+	})
+}
+
+func TestWatch(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantTree[int, int](v)
+		events, cancel := tree.Watch(10, 20, WatchOptions{Buffer: 10})
+
+		tree.PutOne(5, 5)
+		tree.PutOne(10, 10)
+		tree.PutOne(15, 15)
+		tree.PutOne(25, 25)
+		tree.Delete(10, 5)
+
+		wanted := []Event[int, int]{
+			{Type: EventPut, Key: 10, Value: 10},
+			{Type: EventPut, Key: 15, Value: 15},
+			{Type: EventDelete, Key: 10, Value: 10},
+		}
+		for _, want := range wanted {
+			if event := <-events; event != want {
+				t.Errorf("event is %v, want %v", event, want)
+			}
+		}
+
+		cancel()
+		tree.PutOne(11, 11)
+		if _, ok := <-events; ok {
+			t.Errorf("the channel should be closed after cancel()")
+		}
+		cancel()
+	})
+}
+
+func TestWatchBlocking(t *testing.T) {
+	tree := NewTree[int, int]()
+	events, cancel := tree.Watch(0, 100, WatchOptions{})
+
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 10; i++ {
+			tree.PutOne(i, i)
+		}
+		done <- true
+	}()
+	for i := 0; i < 10; i++ {
+		if event := <-events; event.Key != i {
+			t.Errorf("event key is %d, want %d", event.Key, i)
+		}
+	}
+	<-done
+
+	//cancelling releases a blocked writer
+	go func() {
+		tree.PutOne(50, 50)
+		done <- true
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the writer is still blocked after cancel()")
+	}
+}
+
+func TestWatchBackpressure(t *testing.T) {
+	forEachVariant(t, func(t *testing.T, v variant) {
+		tree := newVariantTree[int, int](v)
+		newest, cancelNewest := tree.Watch(0, 100, WatchOptions{Buffer: 2, Backpressure: BackpressureDropNewest})
+		defer cancelNewest()
+		oldest, cancelOldest := tree.Watch(0, 100, WatchOptions{Buffer: 2, Backpressure: BackpressureDropOldest})
+		defer cancelOldest()
+		closing, cancelClosing := tree.Watch(0, 100, WatchOptions{Buffer: 2, Backpressure: BackpressureClose})
+		defer cancelClosing()
+
+		for i := 0; i < 5; i++ {
+			tree.PutOne(i, i)
+		}
+
+		if a, b := <-newest, <-newest; a.Key != 0 || b.Key != 1 {
+			t.Errorf("DropNewest keeps %d and %d, want 0 and 1", a.Key, b.Key)
+		}
+		if a, b := <-oldest, <-oldest; a.Key != 3 || b.Key != 4 {
+			t.Errorf("DropOldest keeps %d and %d, want 3 and 4", a.Key, b.Key)
+		}
+		received := 0
+		for range closing {
+			received++
+		}
+		if received != 2 {
+			t.Errorf("Close delivers %d events before closing, want 2", received)
+		}
+		//the closed watcher is dropped : the next changes aren't sent to it anymore
+		if watchers := len(coreOf[int, int](tree).observers.watchers); watchers != 2 {
+			t.Errorf("the tree has %d watchers, want 2", watchers)
+		}
+	})
+}